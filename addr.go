@@ -0,0 +1,121 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// DeterministicAddresses returns an Option that orders func and chan
+// values, which are otherwise ordered by machine address, by the
+// order in which they first appear in the slice instead: scanning the
+// elements in index order, and each element's fields in the order
+// they're compared. Equal values rank equally, and nil values order
+// first. The result is reproducible from run to run, and between
+// copies of the same data, as in snapshot tests.
+//
+// Only func and chan values reached through struct fields and array
+// elements are ranked, not those behind pointers or in interfaces.
+//
+// Ranking the values requires a pass over the slice and a map of its
+// distinct func and chan values when the less function is built.
+// DeterministicAddresses only affects OfOpts.
+func DeterministicAddresses() Option {
+	return func(c *config) {
+		c.detAddrs = true
+		c.applied("DeterministicAddresses")
+	}
+}
+
+// Strict returns an Option that makes an ordering an error if it
+// would depend on machine addresses, which differ from run to run:
+// those of pointers not followed with Deref, maps without DeepMaps,
+// and funcs, chans and unsafe pointers not ranked by
+// DeterministicAddresses. OfOpts panics, and OfOptsErr returns an
+// error, naming the field path and type of the first such value, so
+// tests can catch accidental nondeterminism.
+//
+// Values in interfaces are checked when their dynamic types are first
+// compared, so an address-ordered one panics then instead.
+func Strict() Option {
+	return func(c *config) {
+		c.strict = true
+		c.applied("Strict")
+		// Strict checks the ordering rather than changing it,
+		// so it's never reported unused by ValidateOpts.
+		c.use("Strict")
+	}
+}
+
+// addrOrdered records in c that the value of type t at path would be
+// ordered by address, as forbidden by Strict, as for fail.
+func (c *config) addrOrdered(path string, t reflect.Type) less {
+	if path == "" {
+		return c.fail(fmt.Errorf("lesser: Strict: type %v (kind %v) orders by address", t, t.Kind()))
+	}
+	return c.fail(fmt.Errorf("lesser: Strict: field %s: type %v (kind %v) orders by address", path, t, t.Kind()))
+}
+
+// addrRanks returns the rank of each distinct non-nil func and chan
+// value in the slice rv, as described by DeterministicAddresses.
+func (c *config) addrRanks(rv reflect.Value) map[unsafe.Pointer]int {
+	rank := map[unsafe.Pointer]int{}
+	et := rv.Type().Elem()
+	offs := c.addrOffsets(et, 0, nil)
+	if len(offs) == 0 {
+		return rank
+	}
+	addr0, size := unsafe.Pointer(rv.Index(0).UnsafeAddr()), et.Size()
+	for i := 0; i < rv.Len(); i++ {
+		p := elem(addr0, size, i)
+		for _, off := range offs {
+			w := *(*unsafe.Pointer)(at(p, off))
+			if _, ok := rank[w]; w != nil && !ok {
+				rank[w] = len(rank) + 1
+			}
+		}
+	}
+	return rank
+}
+
+// addrOffsets appends to offs the offsets of the func and chan values
+// within a value of type t at offset off, in comparison order.
+func (c *config) addrOffsets(t reflect.Type, off uintptr, offs []uintptr) []uintptr {
+	if !hasDefaultOrder(t) {
+		return offs
+	}
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func:
+		offs = append(offs, off)
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			offs = c.addrOffsets(t.Elem(), off+uintptr(i)*t.Elem().Size(), offs)
+		}
+	case reflect.Struct:
+		for _, sf := range c.sortFields(t) {
+			offs = c.addrOffsets(sf.Type, off+sf.Offset, offs)
+		}
+	}
+	return offs
+}
+
+// lessAddrRank orders pointer-shaped values by their rank; nil values
+// have rank zero.
+func lessAddrRank(rank map[unsafe.Pointer]int) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			ra, rb := rank[*(*unsafe.Pointer)(at(a, off))], rank[*(*unsafe.Pointer)(at(b, off))]
+			if ra == rb {
+				if optEq != nil {
+					return optEq(a, b)
+				}
+				return false
+			}
+			return ra < rb
+		}
+	}
+}
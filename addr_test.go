@@ -0,0 +1,124 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type handler struct {
+	Name string
+	Fn   func() string
+	Done [2]chan bool
+}
+
+func TestDeterministicAddresses(t *testing.T) {
+	// Closures capturing different values are distinct funcs, with
+	// addresses unrelated to their order here.
+	mkFn := func(s string) func() string { return func() string { return s } }
+	f1, f2, f3 := mkFn("1"), mkFn("2"), mkFn("3")
+	ch1, ch2 := make(chan bool), make(chan bool)
+
+	orig := []handler{
+		{"x", f3, [2]chan bool{ch2, nil}},
+		{"x", f1, [2]chan bool{nil, nil}},
+		{"x", f3, [2]chan bool{ch1, nil}},
+		{"x", nil, [2]chan bool{}},
+		{"x", f2, [2]chan bool{}},
+		{"x", f1, [2]chan bool{nil, ch1}},
+	}
+	type result struct {
+		Fn   string
+		Done [2]chan bool
+	}
+	// nil first, then funcs in order of first appearance (f3, f1,
+	// f2), and within f3's, ch2 was seen before ch1.
+	want := []result{
+		{"nil", [2]chan bool{}},
+		{"3", [2]chan bool{ch2, nil}},
+		{"3", [2]chan bool{ch1, nil}},
+		{"1", [2]chan bool{nil, nil}},
+		{"1", [2]chan bool{nil, ch1}},
+		{"2", [2]chan bool{}},
+	}
+	// Sorting clones of the data gives the same result each time.
+	for i := 0; i < 3; i++ {
+		in := append([]handler(nil), orig...)
+		sort.Slice(in, OfOpts(in, DeterministicAddresses()))
+		var got []result
+		for _, h := range in {
+			r := result{"nil", h.Done}
+			if h.Fn != nil {
+				r.Fn = h.Fn()
+			}
+			got = append(got, r)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("sort %d: got %v; want %v", i, got, want)
+		}
+	}
+	if err := ValidateOpts(reflect.TypeOf(handler{}), DeterministicAddresses()); err != nil {
+		t.Errorf("ValidateOpts: %v", err)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	type wrapper struct {
+		Meta map[string]int
+		N    node
+	}
+	_, err := OfOptsErr(make([]wrapper, 2), Strict())
+	if err == nil || !strings.Contains(err.Error(), "field N.Next: type *lesser.node") {
+		t.Errorf("OfOptsErr error = %v; want one naming N.Next", err)
+	}
+	func() {
+		defer func() {
+			if e := recover(); e == nil || !strings.Contains(e.(string), "orders by address") {
+				t.Errorf("OfOpts recovered %v; want Strict panic", e)
+			}
+		}()
+		OfOpts(make([]*node, 2), Strict())
+	}()
+
+	// Following pointers, comparing maps by contents and ranking
+	// handlers' funcs and chans leaves nothing ordered by address.
+	for _, tt := range []struct {
+		slice interface{}
+		opts  []Option
+	}{
+		{make([]wrapper, 2), []Option{Strict(), Deref(), DeepMaps()}},
+		{make([]handler, 2), []Option{Strict(), DeterministicAddresses()}},
+		{make([]string, 2), []Option{Strict()}},
+	} {
+		if _, err := OfOptsErr(tt.slice, tt.opts...); err != nil {
+			t.Errorf("%T: %v", tt.slice, err)
+		}
+	}
+	if err := ValidateOpts(reflect.TypeOf(""), Strict()); err != nil {
+		t.Errorf("ValidateOpts: %v", err)
+	}
+
+	// Dynamic types in interfaces are checked when compared.
+	in := []interface{}{new(int), new(int)}
+	less, err := OfOptsErr(in, Strict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	func() {
+		defer func() {
+			if e := recover(); e == nil {
+				t.Error("comparing *int in interface didn't panic")
+			}
+		}()
+		less(0, 1)
+	}()
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.19
+
+package lesser
+
+import (
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+func init() {
+	// The typed atomics would otherwise order by their unexported
+	// value fields, read without synchronization. Load them
+	// instead. Each is loaded atomically, but the two loads of a
+	// comparison aren't a snapshot: values that change while
+	// being sorted can still make the order inconsistent.
+	register(reflect.TypeOf(atomic.Bool{}), func(a, b unsafe.Pointer) int {
+		return cmpBool((*atomic.Bool)(a).Load(), (*atomic.Bool)(b).Load())
+	})
+	register(reflect.TypeOf(atomic.Int32{}), func(a, b unsafe.Pointer) int {
+		return cmpInt64(int64((*atomic.Int32)(a).Load()), int64((*atomic.Int32)(b).Load()))
+	})
+	register(reflect.TypeOf(atomic.Int64{}), func(a, b unsafe.Pointer) int {
+		return cmpInt64((*atomic.Int64)(a).Load(), (*atomic.Int64)(b).Load())
+	})
+	register(reflect.TypeOf(atomic.Uint32{}), func(a, b unsafe.Pointer) int {
+		return cmpUint64(uint64((*atomic.Uint32)(a).Load()), uint64((*atomic.Uint32)(b).Load()))
+	})
+	register(reflect.TypeOf(atomic.Uint64{}), func(a, b unsafe.Pointer) int {
+		return cmpUint64((*atomic.Uint64)(a).Load(), (*atomic.Uint64)(b).Load())
+	})
+	register(reflect.TypeOf(atomic.Uintptr{}), func(a, b unsafe.Pointer) int {
+		return cmpUint64(uint64((*atomic.Uintptr)(a).Load()), uint64((*atomic.Uintptr)(b).Load()))
+	})
+}
+
+func cmpBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
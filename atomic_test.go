@@ -0,0 +1,59 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.19
+
+package lesser
+
+import (
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAtomics(t *testing.T) {
+	type counter struct {
+		Hits  atomic.Int64
+		Ready atomic.Bool
+		Max   atomic.Uint32
+		ID    int
+	}
+	newCounter := func(hits int64, ready bool, max uint32, id int) *counter {
+		c := &counter{ID: id}
+		c.Hits.Store(hits)
+		c.Ready.Store(ready)
+		c.Max.Store(max)
+		return c
+	}
+	in := []*counter{
+		newCounter(5, true, 1, 0),
+		newCounter(-3, false, 0, 1),
+		newCounter(5, false, 7, 2),
+		newCounter(5, true, 0, 3),
+		newCounter(5, false, 7, 4),
+	}
+	sort.Slice(in, OfOpts(in, Deref()))
+	var got []int
+	for _, c := range in {
+		got = append(got, c.ID)
+	}
+	want := []int{1, 2, 4, 3, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v; want %v", got, want)
+		}
+	}
+
+	// Values are loaded atomically, so sorting while they change
+	// is no data race, though the result is unpredictable.
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			in[i%len(in)].Hits.Add(1)
+		}
+		close(done)
+	}()
+	sort.Slice(in, OfOpts(in, Deref()))
+	<-done
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"bytes"
+	"hash/crc32"
+	"unsafe"
+)
+
+// SampledBytes returns an Option that orders byte slices (any slice
+// whose element kind is uint8, such as []byte) with an order that is
+// cheap to compute for large values, rather than lexicographically.
+// Slices of byte types with their own ordering, such as a Cmp method,
+// keep comparing their elements by it.
+//
+// Byte slices are compared by length first, then by a CRC-32 of
+// their first n and last n bytes, and only if those are equal by
+// their full contents with bytes.Compare. This is a total order:
+// two byte slices are equal only if their contents are equal.
+// But it is not lexicographic order, and isn't meaningful to humans;
+// it's intended for sorting records keyed by large blobs, where only
+// a consistent order matters.
+//
+// Each comparison of two equal-length blobs costs O(n) in the common
+// case. Comparisons of equal blobs, or of blobs whose samples collide,
+// cost O(length).
+//
+// SampledBytes panics if n is not positive.
+func SampledBytes(n int) Option {
+	if n <= 0 {
+		panic("lesser: SampledBytes sample size must be positive")
+	}
+	return func(c *config) {
+		c.byteSample = n
+		c.applied("SampledBytes", n)
+	}
+}
+
+func lessSampledBytes(n int) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := *(*[]byte)(at(a, off)), *(*[]byte)(at(b, off))
+			if len(va) != len(vb) {
+				return len(va) < len(vb)
+			}
+			if ha, hb := sampleHash(va, n), sampleHash(vb, n); ha != hb {
+				return ha < hb
+			}
+			if cmp := bytes.Compare(va, vb); cmp != 0 {
+				return cmp < 0
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// lessBytes is the leaf for slices of bytes, which compare as by
+// bytes.Compare: lexicographically, with a prefix first.
+func lessBytes(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*[]byte)(at(a, off)), *(*[]byte)(at(b, off))
+		if c := bytes.Compare(va, vb); c != 0 {
+			return c < 0
+		}
+		if optEq != nil {
+			return optEq(a, b)
+		}
+		return false
+	}
+}
+
+// sampleHash returns the CRC-32 of the first n and last n bytes of b,
+// or of all of b if it's not longer than 2*n.
+func sampleHash(b []byte, n int) uint32 {
+	if len(b) <= 2*n {
+		return crc32.ChecksumIEEE(b)
+	}
+	h := crc32.Update(0, crc32.IEEETable, b[:n])
+	return crc32.Update(h, crc32.IEEETable, b[len(b)-n:])
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type blobRecord struct {
+	Blob []byte
+	N    int
+}
+
+func TestSampledBytes(t *testing.T) {
+	big := func(fill byte, mid byte) []byte {
+		b := bytes.Repeat([]byte{fill}, 1000)
+		b[500] = mid // outside the samples
+		return b
+	}
+	recs := []blobRecord{
+		{big('a', 'x'), 1},
+		{[]byte("zz"), 2},
+		{big('a', 'y'), 3},
+		{nil, 4},
+		{big('a', 'x'), 0},
+		{[]byte("ab"), 5},
+		{big('b', 'x'), 6},
+	}
+	sort.Slice(recs, OfOpts(recs, SampledBytes(16)))
+
+	for i := 1; i < len(recs); i++ {
+		a, b := recs[i-1], recs[i]
+		if len(a.Blob) > len(b.Blob) {
+			t.Errorf("recs[%d] longer than recs[%d]", i-1, i)
+		}
+		if bytes.Equal(a.Blob, b.Blob) && a.N > b.N {
+			t.Errorf("equal blobs %d and %d not tie-broken by N", i-1, i)
+		}
+	}
+	if recs[0].N != 4 {
+		t.Errorf("empty blob not first; got N=%d", recs[0].N)
+	}
+	// The two equal blobs must be adjacent, with the differing-only-in-the-middle
+	// one not between them.
+	for i := range recs {
+		if recs[i].N == 0 {
+			if i+1 == len(recs) || recs[i+1].N != 1 {
+				t.Errorf("equal blobs not adjacent: %v", recs)
+			}
+		}
+	}
+}
+
+// revByte is a byte type ordered in reverse by its Cmp method.
+type revByte byte
+
+func (a revByte) Cmp(b revByte) int { return int(b) - int(a) }
+
+func TestSampledBytesCustomOrder(t *testing.T) {
+	// Elements with their own ordering are compared by it, not
+	// sampled as raw bytes.
+	in := [][]revByte{{1, 2}, {9}, {1, 3}, {5, 5, 5}}
+	sort.Slice(in, OfOpts(in, SampledBytes(1)))
+	want := [][]revByte{{9}, {5, 5, 5}, {1, 3}, {1, 2}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+	if err := ValidateOpts(reflect.TypeOf(in).Elem(), SampledBytes(1)); err == nil {
+		t.Error("ValidateOpts: SampledBytes reported used for []revByte")
+	}
+}
+
+type digest []byte
+
+func TestBytesLexicographic(t *testing.T) {
+	in := [][]byte{[]byte("b"), []byte("ab"), nil, []byte("a"), {}, []byte("abc")}
+	sort.Slice(in, Of(in))
+	want := []string{"", "", "a", "ab", "abc", "b"}
+	for i, b := range in {
+		if string(b) != want[i] {
+			t.Fatalf("[][]byte: got %q; want %q", in, want)
+		}
+	}
+
+	ds := []digest{digest("\xff"), digest("\x00\x01"), digest("\x00")}
+	sort.Slice(ds, Of(ds))
+	if string(ds[0]) != "\x00" || string(ds[1]) != "\x00\x01" || string(ds[2]) != "\xff" {
+		t.Errorf("named byte slices: got %q", ds)
+	}
+
+	recs := []blobRecord{{[]byte("ab"), 2}, {[]byte("a"), 1}, {nil, 3}, {[]byte("ab"), 1}, {[]byte{}, 0}}
+	sort.Slice(recs, Of(recs))
+	var got []string
+	for _, r := range recs {
+		got = append(got, fmt.Sprintf("%s/%d", r.Blob, r.N))
+	}
+	if want := []string{"/0", "/3", "a/1", "ab/1", "ab/2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("struct: got %q; want %q", got, want)
+	}
+}
+
+func BenchmarkByteKeys(b *testing.B) {
+	const n = 1000
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("user/%08d/profile", (i*7919)%n))
+	}
+	b.Run("bytes", func(b *testing.B) {
+		s := make([][]byte, n)
+		for i := 0; i < b.N; i++ {
+			copy(s, keys)
+			sort.Slice(s, Of(s))
+		}
+	})
+	// The same keys as []uint16, compared element by element.
+	b.Run("generic", func(b *testing.B) {
+		wide := make([][]uint16, n)
+		for i, k := range keys {
+			for _, c := range k {
+				wide[i] = append(wide[i], uint16(c))
+			}
+		}
+		s := make([][]uint16, n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			copy(s, wide)
+			sort.Slice(s, Of(s))
+		}
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// lessCache holds the element orderings built by ofValue, so that
+// calling Of repeatedly for slices of the same type doesn't rebuild
+// them. An ordering depends only on the element type, the options,
+// and the package registry, not on the slice it was built for.
+var lessCache sync.Map // lessKey => less
+
+// lessKey identifies an ordering in lessCache.
+type lessKey struct {
+	t reflect.Type
+
+	// opts is the canonical encoding of the options: their names
+	// and arguments, in the order applied, since later options
+	// can override earlier ones. See config.applied.
+	opts string
+
+	// gen is the registry generation the ordering was built in.
+	// Registering a type changes the key, so orderings built
+	// before then aren't found.
+	gen int
+}
+
+// Compile returns the ordering Of uses for values of type t, as a
+// function reporting whether the value at address a orders before the
+// one at b. It's shared with Of and other calls to Compile, so only
+// the first call for a type does the work of building it.
+//
+// It returns an error if values of type t can't be ordered.
+func Compile(t reflect.Type) (less func(a, b unsafe.Pointer) bool, err error) {
+	c := newConfig(nil)
+	l := c.cachedLess(t)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return l, nil
+}
+
+// CompiledFor is like Of, but uses Compile for the element type of
+// slice, and binds the result to slice with no further work. It
+// panics if slice isn't a slice or its elements can't be ordered.
+func CompiledFor(slice interface{}) (less func(i, j int) bool) {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("lesser: CompiledFor argument of type %T is not a slice", slice))
+	}
+	et := rv.Type().Elem()
+	elemLess, err := Compile(et)
+	if err != nil {
+		panic(err.Error())
+	}
+	if rv.Len() == 0 {
+		return nil // won't be called
+	}
+	return bind(elemLess, unsafe.Pointer(rv.Index(0).UnsafeAddr()), et.Size())
+}
+
+// cachedLess is like lessElem with a nil optEq, but returns a shared
+// ordering if one was already built for an equivalent config.
+//
+// Options whose orderings depend on a particular slice, such as
+// DerefStable, TieBreak and DeterministicAddresses, are handled by
+// ofValue without the cache.
+func (c *config) cachedLess(et reflect.Type) less {
+	if c.uncacheable {
+		return c.lessElem(et, nil)
+	}
+	k := lessKey{
+		t:    et,
+		opts: strings.Join(c.key, "\x00"),
+		gen:  registryGeneration(),
+	}
+	if v, ok := lessCache.Load(k); ok {
+		return v.(less)
+	}
+	l := c.lessElem(et, nil)
+	if c.err != nil {
+		return l // not to be used, nor cached
+	}
+	v, _ := lessCache.LoadOrStore(k, l)
+	return v.(less)
+}
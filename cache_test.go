@@ -0,0 +1,133 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"unsafe"
+)
+
+func TestCacheKeyIncludesOptions(t *testing.T) {
+	sorted := func(opts ...Option) []string {
+		s := []string{"b", "B", "a", "C"}
+		sort.Slice(s, OfOpts(s, opts...))
+		return s
+	}
+	// Each twice, so the second of each is served from the cache.
+	for i := 0; i < 2; i++ {
+		if got, want := sorted(Fold()), []string{"a", "B", "b", "C"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Fold: got %q; want %q", got, want)
+		}
+		if got, want := sorted(Desc()), []string{"b", "a", "C", "B"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Desc: got %q; want %q", got, want)
+		}
+		if got, want := sorted(), []string{"B", "C", "a", "b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("default: got %q; want %q", got, want)
+		}
+		if got, want := sorted(Fold(), Desc()), []string{"C", "b", "B", "a"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Fold, Desc: got %q; want %q", got, want)
+		}
+	}
+}
+
+func TestCacheKeyIncludesArgs(t *testing.T) {
+	s := []float64{1.04, 1.01}
+	if !OfOpts(s, FloatEpsilon(0.01))(1, 0) {
+		t.Error("with eps 0.01, 1.01 should order before 1.04")
+	}
+	if OfOpts(s, FloatEpsilon(0.5))(1, 0) {
+		t.Error("with eps 0.5, 1.01 and 1.04 should be equal")
+	}
+	names := []string{"b", "a"}
+	opt := StringEnumOrder("", names)
+	names[0] = "a" // must not affect opt
+	if !OfOpts([]string{"a", "b"}, opt)(1, 0) {
+		t.Error("StringEnumOrder affected by later change to names")
+	}
+}
+
+func TestCacheKeyDistinguishesEncodings(t *testing.T) {
+	a := newConfig([]Option{StringIgnoring("-"), Fold()})
+	b := newConfig([]Option{StringIgnoring("-Fold")})
+	if reflect.DeepEqual(a.key, b.key) {
+		t.Errorf("different options share key %q", a.key)
+	}
+}
+
+// laterRegistered is only registered by TestCacheRegistration, which
+// reverses its ordering each time it runs.
+type laterRegistered int
+
+func TestCacheRegistration(t *testing.T) {
+	s := []laterRegistered{1, 2}
+	before := Of(s)(0, 1) // true, unless an earlier run registered
+	register(reflect.TypeOf(laterRegistered(0)), func(a, b unsafe.Pointer) int {
+		c := int(*(*laterRegistered)(a) - *(*laterRegistered)(b))
+		if before {
+			return -c
+		}
+		return c
+	})
+	if Of(s)(0, 1) == before {
+		t.Error("cached ordering used after registering a new one")
+	}
+}
+
+func BenchmarkOfCached(b *testing.B) {
+	s := []TStringInt{{"a", 1}, {"b", 2}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Of(s)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	less, err := Compile(reflect.TypeOf(TStringInt{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := TStringInt{"a", 2}, TStringInt{"b", 1}
+	if !less(unsafe.Pointer(&a), unsafe.Pointer(&b)) || less(unsafe.Pointer(&b), unsafe.Pointer(&a)) {
+		t.Error("wrong order")
+	}
+
+	s := []TStringInt{{"b", 1}, {"a", 2}, {"a", 1}}
+	sort.Slice(s, CompiledFor(s))
+	if want := []TStringInt{{"a", 1}, {"a", 2}, {"b", 1}}; !reflect.DeepEqual(s, want) {
+		t.Errorf("got %v; want %v", s, want)
+	}
+}
+
+// smallSlices returns n slices of a few elements each, as a hot loop
+// sorting many small batches would see.
+func smallSlices(n int) [][]TStringInt {
+	ss := make([][]TStringInt, n)
+	for i := range ss {
+		ss[i] = []TStringInt{{"c", i}, {"a", i % 3}, {"b", i}, {"a", i % 5}}
+	}
+	return ss
+}
+
+func BenchmarkSmallSlicesOf(b *testing.B) {
+	ss := smallSlices(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, s := range ss {
+			sort.Slice(s, Of(s))
+		}
+	}
+}
+
+func BenchmarkSmallSlicesCompiledFor(b *testing.B) {
+	ss := smallSlices(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, s := range ss {
+			sort.Slice(s, CompiledFor(s))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// WeekStart returns an Option that orders time.Weekday values as days
+// of a week beginning on start, rather than numerically (which puts
+// Sunday first). For example, WeekStart(time.Monday) orders Sunday
+// last.
+func WeekStart(start time.Weekday) Option {
+	return func(c *config) {
+		c.setType(reflect.TypeOf(time.Weekday(0)), typeRule{
+			name: "WeekStart",
+			cmp: func(a, b unsafe.Pointer) int {
+				return cmpCyclic(int64(*(*time.Weekday)(a)), int64(*(*time.Weekday)(b)), int64(start), 7)
+			},
+		}, start)
+	}
+}
+
+// FiscalYearStart returns an Option that orders time.Month values as
+// months of a year beginning in start, rather than in January. For
+// example, FiscalYearStart(time.April) orders January through March
+// after December.
+func FiscalYearStart(start time.Month) Option {
+	return func(c *config) {
+		c.setType(reflect.TypeOf(time.Month(0)), typeRule{
+			name: "FiscalYearStart",
+			cmp: func(a, b unsafe.Pointer) int {
+				return cmpCyclic(int64(*(*time.Month)(a)), int64(*(*time.Month)(b)), int64(start), 12)
+			},
+		}, start)
+	}
+}
+
+// cmpCyclic compares a and b by their distance past start in a cycle
+// of length n, and then, for out-of-range values that share a
+// position in the cycle, by value.
+func cmpCyclic(a, b, start, n int64) int {
+	ra, rb := ((a-start)%n+n)%n, ((b-start)%n+n)%n
+	if ra != rb {
+		return cmpInt64(ra, rb)
+	}
+	return cmpInt64(a, b)
+}
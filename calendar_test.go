@@ -0,0 +1,54 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	all := []time.Weekday{time.Saturday, time.Sunday, time.Wednesday, time.Monday, time.Friday, time.Tuesday, time.Thursday}
+	tests := []struct {
+		start time.Weekday
+		want  []time.Weekday
+	}{
+		{time.Sunday, []time.Weekday{0, 1, 2, 3, 4, 5, 6}},
+		{time.Monday, []time.Weekday{1, 2, 3, 4, 5, 6, 0}},
+		{time.Saturday, []time.Weekday{6, 0, 1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		got := append([]time.Weekday(nil), all...)
+		sort.Slice(got, OfOpts(got, WeekStart(tt.start)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WeekStart(%v) = %v; want %v", tt.start, got, tt.want)
+		}
+	}
+}
+
+func TestFiscalYearStart(t *testing.T) {
+	type entry struct {
+		Month time.Month
+		Day   int
+	}
+	in := []entry{{time.March, 1}, {time.April, 2}, {time.December, 3}, {time.January, 4}, {time.April, 1}, {time.June, 9}}
+	tests := []struct {
+		start time.Month
+		want  []entry
+	}{
+		{time.January, []entry{{time.January, 4}, {time.March, 1}, {time.April, 1}, {time.April, 2}, {time.June, 9}, {time.December, 3}}},
+		{time.April, []entry{{time.April, 1}, {time.April, 2}, {time.June, 9}, {time.December, 3}, {time.January, 4}, {time.March, 1}}},
+		{time.October, []entry{{time.December, 3}, {time.January, 4}, {time.March, 1}, {time.April, 1}, {time.April, 2}, {time.June, 9}}},
+	}
+	for _, tt := range tests {
+		got := append([]entry(nil), in...)
+		sort.Slice(got, OfOpts(got, FiscalYearStart(tt.start)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FiscalYearStart(%v) = %v; want %v", tt.start, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package collate provides lesser orderings of strings that follow
+// the collation rules of a language, using golang.org/x/text/collate.
+// It's a separate module so that users of lesser who don't need it
+// don't depend on golang.org/x/text.
+package collate // import "github.com/bradfitz/lesser/collate"
+
+import (
+	"sync"
+
+	"github.com/bradfitz/lesser"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Strings returns a lesser Option that orders strings by the collation
+// rules of the language tag, with opts as for collate.New. For example,
+// with language.Swedish, "å", "ä" and "ö" order after "z", and with
+// language.German, "ß" orders like "ss".
+//
+// Each call creates one collator, which is shared by all comparisons
+// of the less functions built with the returned Option. Comparisons
+// are serialized, since a collator isn't safe for concurrent use.
+func Strings(tag language.Tag, opts ...collate.Option) lesser.Option {
+	var (
+		mu sync.Mutex
+		c  = collate.New(tag, opts...)
+	)
+	return lesser.StringOrder(func(a, b string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return c.CompareString(a, b)
+	})
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package collate
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bradfitz/lesser"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+func TestStrings(t *testing.T) {
+	type city struct {
+		Name string
+		ID   int
+	}
+	names := func(in []city) (ret []string) {
+		for _, c := range in {
+			ret = append(ret, c.Name)
+		}
+		return ret
+	}
+	in := []city{{"Örebro", 1}, {"Zürich", 2}, {"Åre", 3}, {"Ängelholm", 4}, {"Aachen", 5}, {"éclair", 6}}
+
+	sort.Slice(in, lesser.OfOpts(in, Strings(language.Swedish)))
+	if got, want := names(in), []string{"Aachen", "éclair", "Zürich", "Åre", "Ängelholm", "Örebro"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Swedish: got %q\nwant %q", got, want)
+	}
+
+	sort.Slice(in, lesser.OfOpts(in, Strings(language.German)))
+	if got, want := names(in), []string{"Aachen", "Ängelholm", "Åre", "éclair", "Örebro", "Zürich"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("German: got %q\nwant %q", got, want)
+	}
+
+	// Strings the collator considers equal fall back to raw order.
+	words := []string{"Straße", "strasse", "Strasse"}
+	sort.Slice(words, lesser.OfOpts(words, Strings(language.German, collate.Loose)))
+	if want := []string{"Strasse", "Straße", "strasse"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("Loose: got %q\nwant %q", words, want)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/bradfitz/lesser/collate
+
+go 1.26.0
+
+require (
+	github.com/bradfitz/lesser v0.0.0
+	golang.org/x/text v0.42.0
+)
+
+replace github.com/bradfitz/lesser => ../
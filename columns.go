@@ -0,0 +1,145 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// A SortColumn describes a top-level field of a struct type that
+// participates in its ordering.
+type SortColumn struct {
+	FieldIndex int  // index of the field, as for reflect.Type.Field
+	Descending bool // whether the field orders in reverse
+}
+
+// SortColumns returns the top-level fields of the struct type t that
+// the ordering of OfOpts with opts compares, in the order they're
+// compared. This is meant for table UIs that show which columns a
+// view is sorted by.
+//
+// With Desc, each column's direction is reversed.
+//
+// It returns nil if t isn't a struct type, or if it is but isn't
+// compared field by field, such as if it has a Cmp or Less method, or
+// opts include HashOrder.
+func SortColumns(t reflect.Type, opts ...Option) []SortColumn {
+	if t.Kind() != reflect.Struct || !hasDefaultOrder(t) {
+		return nil
+	}
+	c := newConfig(opts)
+	if c.hashOrder {
+		return nil
+	}
+	var cols []SortColumn
+	for _, sf := range c.sortFields(t) {
+		cols = append(cols, SortColumn{FieldIndex: sf.Index[0], Descending: fieldDesc(sf) != c.desc})
+	}
+	return cols
+}
+
+// A SortKey is one term of a multi-column ordering built by BySpec,
+// like one expression of an SQL ORDER BY clause.
+type SortKey struct {
+	Field     string // field path, as for Modulo
+	Desc      bool   // order from greatest to least
+	NullsLast bool   // order nil pointers last rather than first
+}
+
+// BySpec returns a less function suitable for passing to sort.Slice
+// that orders the elements of slice, a slice of structs, by the
+// fields named in spec, in turn, as a query engine would for an ORDER
+// BY clause known only at run time. Elements equal on all of them are
+// equal.
+//
+// Each field is ordered as by Of, except that nullable fields order
+// NULLs first or, with NullsLast, last, as with the NilsLast option.
+// Those are pointer fields, which order by the values they point to,
+// with nil as NULL, and database/sql's nullable types, such as
+// sql.NullInt64. Desc reverses the order of the values but, as in
+// SQL, not where the NULLs go.
+//
+// BySpec returns an error if spec is empty or names a field more than
+// once, if a field doesn't exist, or if NullsLast is set for a field
+// that isn't nullable, or if a field can't be ordered. It panics if
+// slice isn't a slice.
+func BySpec(slice interface{}, spec []SortKey) (func(i, j int) bool, error) {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	if len(spec) == 0 {
+		return nil, errors.New("lesser: BySpec with no sort keys")
+	}
+	et := rv.Type().Elem()
+	fields := make([]reflect.StructField, len(spec))
+	seen := make(map[string]bool)
+	for i, key := range spec {
+		if seen[key.Field] {
+			return nil, fmt.Errorf("lesser: BySpec key %d repeats field %q", i, key.Field)
+		}
+		seen[key.Field] = true
+		sf, ok := fieldByPath(et, key.Field)
+		if !ok || key.Field == "" {
+			return nil, fmt.Errorf("lesser: BySpec key %d: type %v has no field %q", i, et, key.Field)
+		}
+		if _, _, null := sqlNull(sf.Type); key.NullsLast && sf.Type.Kind() != reflect.Ptr && !null {
+			return nil, fmt.Errorf("lesser: BySpec key %d: NullsLast for field %q of type %v, which can't be NULL", i, key.Field, sf.Type)
+		}
+		fields[i] = sf
+	}
+
+	c := newConfig(nil)
+	var ret less
+	for i := len(spec) - 1; i >= 0; i-- {
+		ret = c.lessSortKey(fields[i], spec[i], ret)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	if rv.Len() == 0 {
+		return nil, nil // won't be called
+	}
+	return bind(ret, unsafe.Pointer(rv.Index(0).UnsafeAddr()), et.Size()), nil
+}
+
+// lessSortKey returns a less function for the field sf, ordered as
+// described by key and BySpec. If two fields are equal, the result is
+// that of optEq, if non-nil.
+func (c *config) lessSortKey(sf reflect.StructField, key SortKey, optEq less) less {
+	off, t := sf.Offset, sf.Type
+	// Desc reverses where NULLs go along with the values, so have
+	// them start out on the other side.
+	nullsLast := key.NullsLast != key.Desc
+	var leaf func(off uintptr, optEq less) less
+	if t.Kind() == reflect.Ptr {
+		leaf = c.lessDeref(t, key.Field, nullsLast)
+	} else if _, _, ok := sqlNull(t); ok {
+		leaf = c.lessSQLNull(t, key.Field, nullsLast)
+	} else {
+		leaf = func(off uintptr, optEq less) less { return c.forAddr(off, t, key.Field, optEq) }
+	}
+	return chainDir(leaf(off, nil), key.Desc, optEq)
+}
+
+// chainDir returns a less function that orders by value, reversed if
+// desc is set, and then by optEq, if non-nil.
+func chainDir(value less, desc bool, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		if value(a, b) {
+			return !desc
+		}
+		if value(b, a) {
+			return desc
+		}
+		if optEq != nil {
+			return optEq(a, b)
+		}
+		return false
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"database/sql"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSortColumns(t *testing.T) {
+	type score struct {
+		Team   string
+		Points int `lesser:"desc"`
+	}
+	tests := []struct {
+		name string
+		t    reflect.Type
+		opts []Option
+		want []SortColumn
+	}{
+		{"struct", reflect.TypeOf(TStringInt{}), nil, []SortColumn{{0, false}, {1, false}}},
+		{"tagged", reflect.TypeOf(score{}), nil, []SortColumn{{0, false}, {1, true}}},
+		{"desc", reflect.TypeOf(score{}), []Option{Desc()}, []SortColumn{{0, true}, {1, false}}},
+		{"hash_order", reflect.TypeOf(score{}), []Option{HashOrder()}, nil},
+		{"blank", reflect.TypeOf(blankStruct{}), nil, []SortColumn{{0, false}, {2, false}}},
+		{"nested", reflect.TypeOf(shardRecord{}), nil, []SortColumn{{0, false}, {1, false}}},
+		{"cmp_method", reflect.TypeOf(revInt{}), nil, nil},
+		{"non_struct", reflect.TypeOf(0), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SortColumns(tt.t, tt.opts...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBySpec(t *testing.T) {
+	type address struct {
+		City string
+	}
+	type person struct {
+		Name string
+		Age  *int
+		Home address
+	}
+	n := func(v int) *int { return &v }
+	in := []person{
+		{"ann", n(30), address{"Oslo"}},
+		{"bob", nil, address{"Bergen"}},
+		{"cat", n(25), address{"Oslo"}},
+		{"dan", n(30), address{"Bergen"}},
+		{"eve", nil, address{"Oslo"}},
+	}
+	names := func(s []person) (ret string) {
+		for _, p := range s {
+			ret += p.Name[:1]
+		}
+		return ret
+	}
+	tests := []struct {
+		spec []SortKey
+		want string
+	}{
+		{[]SortKey{{Field: "Home.City"}, {Field: "Name", Desc: true}}, "dbeca"},
+		{[]SortKey{{Field: "Age"}, {Field: "Name"}}, "becad"},
+		{[]SortKey{{Field: "Age", NullsLast: true}, {Field: "Name"}}, "cadbe"},
+		{[]SortKey{{Field: "Age", Desc: true}, {Field: "Name"}}, "beadc"},
+		{[]SortKey{{Field: "Age", Desc: true, NullsLast: true}, {Field: "Home.City"}, {Field: "Name"}}, "dacbe"},
+	}
+	for _, tt := range tests {
+		s := append([]person(nil), in...)
+		less, err := BySpec(s, tt.spec)
+		if err != nil {
+			t.Errorf("BySpec(%+v): %v", tt.spec, err)
+			continue
+		}
+		sort.Slice(s, less)
+		if got := names(s); got != tt.want {
+			t.Errorf("BySpec(%+v) = %s; want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	bad := [][]SortKey{
+		nil,
+		{{Field: "Missing"}},
+		{{Field: "Home.Zip"}},
+		{{Field: ""}},
+		{{Field: "Name"}, {Field: "Name", Desc: true}},
+		{{Field: "Name", NullsLast: true}},
+	}
+	for _, spec := range bad {
+		if _, err := BySpec(in, spec); err == nil {
+			t.Errorf("BySpec(%+v): got nil error", spec)
+		}
+	}
+}
+
+func TestBySpecSQLNull(t *testing.T) {
+	type row struct {
+		Name  string
+		Score sql.NullInt64
+	}
+	score := func(v int64) sql.NullInt64 { return sql.NullInt64{Int64: v, Valid: true} }
+	in := []row{
+		{"ann", score(3)},
+		{"bob", sql.NullInt64{}},
+		{"cat", score(7)},
+		{"dan", sql.NullInt64{Int64: 9}}, // NULL, whatever Int64 holds
+		{"eve", score(3)},
+	}
+	tests := []struct {
+		key  SortKey
+		want string
+	}{
+		{SortKey{Field: "Score"}, "bdaec"},
+		{SortKey{Field: "Score", NullsLast: true}, "aecbd"},
+		{SortKey{Field: "Score", Desc: true}, "bdcae"},
+		{SortKey{Field: "Score", Desc: true, NullsLast: true}, "caebd"},
+	}
+	for _, tt := range tests {
+		s := append([]row(nil), in...)
+		less, err := BySpec(s, []SortKey{tt.key, {Field: "Name"}})
+		if err != nil {
+			t.Fatalf("BySpec(%+v): %v", tt.key, err)
+		}
+		sort.Slice(s, less)
+		var got string
+		for _, r := range s {
+			got += r.Name[:1]
+		}
+		if got != tt.want {
+			t.Errorf("BySpec(%+v) = %s; want %s", tt.key, got, tt.want)
+		}
+	}
+}
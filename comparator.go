@@ -0,0 +1,64 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// A Comparator is an ordering of the elements of slices of one type.
+//
+// The less functions returned by Of are bound to the backing array
+// of the slice they were made for. A Comparator isn't: it's built
+// once, and then bound to a slice's current backing array with
+// Rebind, which is cheap. This makes it safe to keep around while
+// the slice is grown or re-sliced.
+type Comparator struct {
+	typ  reflect.Type // the slice type
+	less less         // compares two element addresses
+}
+
+// NewComparator returns a Comparator for slices of type sliceType,
+// using the ordering rules of OfOpts with opts.
+//
+// It panics if sliceType isn't a slice type or if its elements can't
+// be ordered.
+func NewComparator(sliceType reflect.Type, opts ...Option) *Comparator {
+	if sliceType.Kind() != reflect.Slice {
+		panic("lesser: NewComparator of non-slice type " + sliceType.String())
+	}
+	c := newConfig(opts)
+	less := c.lessElem(sliceType.Elem(), nil)
+	if c.err != nil {
+		panic(c.err.Error())
+	}
+	return &Comparator{typ: sliceType, less: less}
+}
+
+// Rebind returns a less function suitable for passing to sort.Slice
+// along with slice, which must be of the Comparator's slice type.
+//
+// Like those returned by Of, the result is only valid until slice's
+// backing array changes. Call Rebind again after appending to or
+// re-slicing it.
+func (c *Comparator) Rebind(slice interface{}) func(i, j int) bool {
+	rv := reflect.ValueOf(slice)
+	if rv.Type() != c.typ {
+		panic(fmt.Sprintf("lesser: Comparator for %v used with %T", c.typ, slice))
+	}
+	if rv.Len() == 0 {
+		return nil // won't be called
+	}
+	return bind(c.less, unsafe.Pointer(rv.Index(0).UnsafeAddr()), c.typ.Elem().Size())
+}
+
+// SortSlice sorts slice, which must be of the Comparator's slice type,
+// in place. It always uses slice's current backing array.
+func (c *Comparator) SortSlice(slice interface{}) {
+	sort.Slice(slice, c.Rebind(slice))
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestComparatorRebind(t *testing.T) {
+	s := make([]TStringInt, 2)
+	s[0] = TStringInt{"b", 1}
+	s[1] = TStringInt{"a", 1}
+	stale := Of(s)
+
+	// Force a reallocation, then reverse the new array's order.
+	s = append(s, TStringInt{"c", 0})
+	s[0], s[1] = s[1], s[0]
+
+	// The old less function still sees the old backing array,
+	// where s[1] < s[0].
+	if !stale(1, 0) {
+		t.Fatal("expected stale less func to read the old backing array")
+	}
+
+	c := NewComparator(reflect.TypeOf(s))
+	less := c.Rebind(s)
+	if less(1, 0) || !less(0, 1) {
+		t.Error("Rebind didn't see the current backing array")
+	}
+
+	s = append(s, TStringInt{"0", 0}, TStringInt{"b", 0})
+	c.SortSlice(s)
+	want := []TStringInt{{"0", 0}, {"a", 1}, {"b", 0}, {"b", 1}, {"c", 0}}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Comparator.SortSlice = %v; want %v", s, want)
+	}
+
+	s = append(s, TStringInt{"", 9})
+	SortSlice(s)
+	want = append([]TStringInt{{"", 9}}, want...)
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("SortSlice = %v; want %v", s, want)
+	}
+}
+
+func TestComparatorOpts(t *testing.T) {
+	s := []measurement{{1.04, "b"}, {1.01, "a"}}
+	c := NewComparator(reflect.TypeOf(s), FloatEpsilon(0.1))
+	sort.Slice(s, c.Rebind(s))
+	if s[0].Name != "a" {
+		t.Errorf("got %v; want FloatEpsilon to tie, ordering by Name", s)
+	}
+}
+
+func TestComparatorWrongType(t *testing.T) {
+	c := NewComparator(reflect.TypeOf([]int(nil)))
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	c.Rebind([]int8{1})
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// cmpFor returns a three-way comparison of the values of type t at
+// offset off from the two addresses it's given, returning -1, 0 or +1
+// in agreement with forAddr with a nil optEq.
+//
+// Structs and arrays are compared a field or element at a time,
+// stopping at the first that differs, so an equal prefix is compared
+// once, rather than once in each direction as calling a less function
+// both ways would. Values of kinds with default orderings, and types
+// with a registered ordering or Cmp method, are compared directly.
+// Others, and all values once c has options, are compared by calling
+// their less function both ways.
+func (c *config) cmpFor(off uintptr, t reflect.Type, path string) cmpFunc {
+	if _, ok := c.fields[path]; ok || len(c.names) > 0 {
+		return c.cmpByLess(off, t, path)
+	}
+	if cmp := registered(t); cmp != nil {
+		return cmpAt(off, cmp)
+	}
+	if cmp := cmpMethod(t); cmp != nil {
+		return cmpAt(off, cmp)
+	}
+	if !hasDefaultOrder(t) {
+		return c.cmpByLess(off, t, path)
+	}
+	switch k := t.Kind(); {
+	case k == reflect.Bool:
+		return func(a, b unsafe.Pointer) int {
+			va, vb := *(*bool)(at(a, off)), *(*bool)(at(b, off))
+			switch {
+			case va == vb:
+				return 0
+			case vb:
+				return -1
+			}
+			return 1
+		}
+	case isInt(k):
+		return func(a, b unsafe.Pointer) int {
+			return cmpInt64(readInt(k, at(a, off)), readInt(k, at(b, off)))
+		}
+	case isUint(k):
+		return func(a, b unsafe.Pointer) int {
+			return cmpUint64(readUint(k, at(a, off)), readUint(k, at(b, off)))
+		}
+	case k == reflect.Float32:
+		return cmpFloat32At(off)
+	case k == reflect.Float64:
+		return cmpFloat64At(off)
+	case k == reflect.Complex64:
+		return cmpChain(cmpFloat32At(off), cmpFloat32At(off+4))
+	case k == reflect.Complex128:
+		return cmpChain(cmpFloat64At(off), cmpFloat64At(off+8))
+	case k == reflect.String:
+		return func(a, b unsafe.Pointer) int {
+			return strings.Compare(*(*string)(at(a, off)), *(*string)(at(b, off)))
+		}
+	case k == reflect.Chan, k == reflect.Func, k == reflect.Map, k == reflect.Ptr, k == reflect.UnsafePointer:
+		return func(a, b unsafe.Pointer) int {
+			return cmpUint64(uint64(*(*uintptr)(at(a, off))), uint64(*(*uintptr)(at(b, off))))
+		}
+	case k == reflect.Array:
+		et := t.Elem()
+		cmps := make([]cmpFunc, t.Len())
+		for i := range cmps {
+			cmps[i] = c.cmpFor(off+et.Size()*uintptr(i), et, path)
+		}
+		return cmpChain(cmps...)
+	case k == reflect.Struct:
+		var cmps []cmpFunc
+		for _, sf := range c.sortFields(t) {
+			cmp := c.cmpFor(off+sf.Offset, sf.Type, joinPath(path, sf.Name))
+			if fieldDesc(sf) {
+				asc := cmp
+				cmp = func(a, b unsafe.Pointer) int { return -asc(a, b) }
+			}
+			cmps = append(cmps, cmp)
+		}
+		return cmpChain(cmps...)
+	}
+	return c.cmpByLess(off, t, path)
+}
+
+// cmpByLess returns a three-way comparison of the values of type t at
+// offset off that calls their less function in each direction.
+func (c *config) cmpByLess(off uintptr, t reflect.Type, path string) cmpFunc {
+	less := c.forAddr(off, t, path, nil)
+	return func(a, b unsafe.Pointer) int {
+		switch {
+		case less(a, b):
+			return -1
+		case less(b, a):
+			return 1
+		}
+		return 0
+	}
+}
+
+// cmpAt returns cmp applied to the values at offset off.
+func cmpAt(off uintptr, cmp cmpFunc) cmpFunc {
+	return func(a, b unsafe.Pointer) int { return cmp(at(a, off), at(b, off)) }
+}
+
+// cmpChain returns the comparison by each of cmps in turn, until one
+// finds the values differ.
+func cmpChain(cmps ...cmpFunc) cmpFunc {
+	switch len(cmps) {
+	case 0:
+		return func(a, b unsafe.Pointer) int { return 0 }
+	case 1:
+		return cmps[0]
+	}
+	return func(a, b unsafe.Pointer) int {
+		for _, cmp := range cmps {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+func cmpFloat32At(off uintptr) cmpFunc {
+	return func(a, b unsafe.Pointer) int {
+		return cmpFloat64(float64(*(*float32)(at(a, off))), float64(*(*float32)(at(b, off))), false)
+	}
+}
+
+func cmpFloat64At(off uintptr) cmpFunc {
+	return func(a, b unsafe.Pointer) int {
+		return cmpFloat64(*(*float64)(at(a, off)), *(*float64)(at(b, off)), false)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// SortEnum is like SortSlice for slices of integer types whose values
+// are known to lie in the small range [min, max], such as enums. It
+// sorts by counting the occurrences of each value and then refilling
+// the slice, taking linear time and a word of memory for each value
+// between the least and greatest elements.
+//
+// If any element falls outside [min, max], the elements are spread
+// over a range much larger than the slice, or the element type has a
+// custom ordering (see Of), SortEnum falls back to SortSlice.
+//
+// SortEnum panics if slice isn't a slice of integers or if max is
+// less than min.
+func SortEnum(slice interface{}, min, max int64) {
+	if max < min {
+		panic("lesser: SortEnum max is less than min")
+	}
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	et := rv.Type().Elem()
+	k := et.Kind()
+	if !isInt(k) && !isUint(k) {
+		panic("lesser: SortEnum requires a slice of integers")
+	}
+	if !hasDefaultOrder(et) || !countingSort(rv, min, max) {
+		SortSlice(slice)
+	}
+}
+
+// hasDefaultOrder reports whether values of type t are ordered by
+// their kind alone, with no registered comparison, Cmp method, Less
+// method or LessThan method, and aren't database/sql nullable types.
+func hasDefaultOrder(t reflect.Type) bool {
+	if _, _, ok := sqlNull(t); ok {
+		return false
+	}
+	return registered(t) == nil && cmpMethod(t) == nil && lessMethod(t) == nil && orderedMethod(t) == nil
+}
+
+// maxSpanPerElem bounds the range of values countingSort counts, as a
+// multiple of the number of elements, so its memory stays linear.
+const maxSpanPerElem = 8
+
+// countingSort sorts the integer slice rv, all of whose elements must
+// lie in [min, max]. It reports false, leaving rv unmodified, if some
+// element doesn't, or the range from the least element to the
+// greatest is too large to count.
+func countingSort(rv reflect.Value, min, max int64) bool {
+	n := rv.Len()
+	if n < 2 {
+		return true
+	}
+	et := rv.Type().Elem()
+	k, size := et.Kind(), et.Size()
+	addr0 := unsafe.Pointer(rv.Index(0).UnsafeAddr())
+	read := func(i int) int64 {
+		p := elem(addr0, size, i)
+		if isInt(k) {
+			return readInt(k, p)
+		}
+		return int64(readUint(k, p))
+	}
+	lo, hi := int64(math.MaxInt64), int64(math.MinInt64)
+	for i := 0; i < n; i++ {
+		v := read(i)
+		if !isInt(k) && v < 0 || v < min || v > max {
+			// Unsigned values above MaxInt64 read as negative.
+			return false
+		}
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	// The span can't overflow as a uint64, unlike hi-lo.
+	span := uint64(hi) - uint64(lo)
+	if span/maxSpanPerElem > uint64(n) {
+		return false
+	}
+	counts := make([]int, span+1)
+	for i := 0; i < n; i++ {
+		counts[uint64(read(i))-uint64(lo)]++
+	}
+	i := 0
+	for off, c := range counts {
+		for ; c > 0; c-- {
+			writeInt(k, elem(addr0, size, i), lo+int64(off))
+			i++
+		}
+	}
+	return true
+}
+
+// writeInt writes v, which must fit, as an integer of kind k at p.
+func writeInt(k reflect.Kind, p unsafe.Pointer, v int64) {
+	switch k {
+	case reflect.Int:
+		*(*int)(p) = int(v)
+	case reflect.Int8:
+		*(*int8)(p) = int8(v)
+	case reflect.Int16:
+		*(*int16)(p) = int16(v)
+	case reflect.Int32:
+		*(*int32)(p) = int32(v)
+	case reflect.Int64:
+		*(*int64)(p) = v
+	case reflect.Uint:
+		*(*uint)(p) = uint(v)
+	case reflect.Uint8:
+		*(*uint8)(p) = uint8(v)
+	case reflect.Uint16:
+		*(*uint16)(p) = uint16(v)
+	case reflect.Uint32:
+		*(*uint32)(p) = uint32(v)
+	case reflect.Uint64:
+		*(*uint64)(p) = uint64(v)
+	case reflect.Uintptr:
+		*(*uintptr)(p) = uintptr(v)
+	default:
+		panic("unreachable")
+	}
+}
+
+// sortBools sorts the slice of bool kind rv by counting its false
+// values.
+func sortBools(rv reflect.Value) {
+	n := rv.Len()
+	if n < 2 {
+		return
+	}
+	addr0 := unsafe.Pointer(rv.Index(0).UnsafeAddr())
+	falses := 0
+	for i := 0; i < n; i++ {
+		if !*(*bool)(elem(addr0, 1, i)) {
+			falses++
+		}
+	}
+	for i := 0; i < n; i++ {
+		*(*bool)(elem(addr0, 1, i)) = i >= falses
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type priority uint8
+
+const (
+	low priority = iota + 1
+	medium
+	high
+)
+
+func TestSortSliceBools(t *testing.T) {
+	s := []bool{true, false, true, true, false}
+	SortSlice(s)
+	if want := []bool{false, false, true, true, true}; !reflect.DeepEqual(s, want) {
+		t.Errorf("got %v; want %v", s, want)
+	}
+}
+
+func TestSortEnum(t *testing.T) {
+	tests := []struct {
+		name     string
+		in, want interface{}
+		min, max int64
+	}{
+		{"uint8", []priority{high, low, medium, low, high}, []priority{low, low, medium, high, high}, 1, 3},
+		{"int", []int{0, -2, 3, -2, 1}, []int{-2, -2, 0, 1, 3}, -2, 3},
+		{"out_of_range", []int{5, 100, 1}, []int{1, 5, 100}, 0, 10},
+		{"big_uint", []uint64{1 << 63, 2, 1}, []uint64{1, 2, 1 << 63}, 0, 3},
+		{"empty", []int16{}, []int16{}, 0, 1},
+		{"custom_order", []revIntKind{1, 3, 2}, []revIntKind{3, 2, 1}, 1, 3},
+		{"full_range", []int64{math.MaxInt64, 0, math.MinInt64, -1}, []int64{math.MinInt64, -1, 0, math.MaxInt64}, math.MinInt64, math.MaxInt64},
+		{"wide_range", []int{1 << 40, 7, 1 << 39}, []int{7, 1 << 39, 1 << 40}, 0, 1 << 40},
+		{"narrow_values", []int{1<<40 + 2, 1 << 40, 1<<40 + 1}, []int{1 << 40, 1<<40 + 1, 1<<40 + 2}, math.MinInt64, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SortEnum(tt.in, tt.min, tt.max)
+			if !reflect.DeepEqual(tt.in, tt.want) {
+				t.Errorf("got %v; want %v", tt.in, tt.want)
+			}
+		})
+	}
+
+	// Values spread too widely aren't counted, whatever min and max
+	// allow; those close together are.
+	wide := []int64{math.MaxInt64, math.MinInt64}
+	if countingSort(reflect.ValueOf(wide), math.MinInt64, math.MaxInt64) {
+		t.Error("countingSort counted values spanning all of int64")
+	}
+	near := []int64{-3, 5, 0}
+	if !countingSort(reflect.ValueOf(near), math.MinInt64, math.MaxInt64) || near[0] != -3 || near[2] != 5 {
+		t.Errorf("countingSort of nearby values = %v", near)
+	}
+}
+
+// revIntKind orders in reverse via its Cmp method, which SortEnum
+// must respect.
+type revIntKind int
+
+func (a revIntKind) Cmp(b revIntKind) int { return int(b - a) }
+
+func randomBools(n int) []bool {
+	r := rand.New(rand.NewSource(1))
+	s := make([]bool, n)
+	for i := range s {
+		s[i] = r.Intn(2) == 1
+	}
+	return s
+}
+
+func BenchmarkSortBools(b *testing.B) {
+	const n = 1 << 16
+	src := randomBools(n)
+	s := make([]bool, n)
+	b.Run("counting", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(s, src)
+			SortSlice(s)
+		}
+	})
+	b.Run("comparison", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(s, src)
+			sort.Slice(s, Of(s))
+		}
+	})
+}
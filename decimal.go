@@ -0,0 +1,105 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// RegisterDecimal registers t, a struct type representing the
+// fixed-point decimal number units × 10^-scale, to order by numeric
+// value rather than field by field. The named fields must be of
+// signed integer kinds.
+//
+// For example, given
+//
+//	type Money struct {
+//		Units int64
+//		Scale int32
+//	}
+//
+// after RegisterDecimal(reflect.TypeOf(Money{}), "Scale", "Units"),
+// Money{100, 2} (1.00) and Money{1000, 3} (1.000) compare equal and
+// both order after Money{99, 2}.
+//
+// Decimal types that instead have a Cmp method, such as
+// github.com/shopspring/decimal.Decimal, need not be registered;
+// see Of.
+//
+// RegisterDecimal panics if t is not a struct type with the named
+// fields.
+func RegisterDecimal(t reflect.Type, scaleField, unitsField string) {
+	scale := decimalField(t, scaleField)
+	units := decimalField(t, unitsField)
+	register(t, func(a, b unsafe.Pointer) int {
+		return cmpDecimal(
+			readInt(units.Type.Kind(), at(a, units.Offset)),
+			readInt(scale.Type.Kind(), at(a, scale.Offset)),
+			readInt(units.Type.Kind(), at(b, units.Offset)),
+			readInt(scale.Type.Kind(), at(b, scale.Offset)),
+		)
+	})
+}
+
+func decimalField(t reflect.Type, name string) reflect.StructField {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("lesser: RegisterDecimal of non-struct type %v", t))
+	}
+	sf, ok := t.FieldByName(name)
+	if !ok || len(sf.Index) != 1 {
+		panic(fmt.Sprintf("lesser: RegisterDecimal: type %v has no field %q", t, name))
+	}
+	switch sf.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return sf
+	}
+	panic(fmt.Sprintf("lesser: RegisterDecimal: field %v.%s is not a signed integer", t, name))
+}
+
+// cmpDecimal compares ua × 10^-sa with ub × 10^-sb.
+func cmpDecimal(ua, sa, ub, sb int64) int {
+	if sa < sb {
+		return -cmpDecimal(ub, sb, ua, sa)
+	}
+	// Bring b to a's scale. The difference of the scales may not
+	// fit in an int64, but does in a uint64.
+	if ub2, ok := mulPow10(ub, uint64(sa)-uint64(sb)); ok {
+		return cmpInt64(ua, ub2)
+	}
+	// Otherwise b is beyond the range of int64, and so of ua, in
+	// the direction of ub's sign.
+	return -cmpInt64(ub, 0)
+}
+
+// mulPow10 returns v × 10^n and whether it fits in an int64.
+func mulPow10(v int64, n uint64) (int64, bool) {
+	if v == 0 {
+		return 0, true
+	}
+	if n >= 19 {
+		// 10^19 alone exceeds MaxInt64.
+		return 0, false
+	}
+	for ; n > 0; n-- {
+		if v > math.MaxInt64/10 || v < math.MinInt64/10 {
+			return 0, false
+		}
+		v *= 10
+	}
+	return v, true
+}
+
+func cmpInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
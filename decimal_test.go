@@ -0,0 +1,80 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type testMoney struct {
+	Scale int8
+	Units int64
+	Note  string
+}
+
+func init() {
+	RegisterDecimal(reflect.TypeOf(testMoney{}), "Scale", "Units")
+}
+
+type moneyRecord struct {
+	Price testMoney
+	ID    int
+}
+
+func TestRegisterDecimal(t *testing.T) {
+	in := []moneyRecord{
+		{testMoney{3, 1000, ""}, 1}, // 1.000
+		{testMoney{2, 99, ""}, 2},   // 0.99
+		{testMoney{2, 100, ""}, 0},  // 1.00
+		{testMoney{0, -1, ""}, 3},   // -1
+		{testMoney{-2, 1, ""}, 4},   // 100
+		{testMoney{30, math.MaxInt64, ""}, 5},
+	}
+	sort.Slice(in, Of(in))
+	var got []int
+	for _, r := range in {
+		got = append(got, r.ID)
+	}
+	// 1.00 and 1.000 are equal, so they order by ID.
+	want := []int{3, 5, 2, 0, 1, 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got IDs %v; want %v", got, want)
+	}
+}
+
+func TestCmpDecimal(t *testing.T) {
+	tests := []struct {
+		ua, sa, ub, sb int64
+		want           int
+	}{
+		{100, 2, 1000, 3, 0},
+		{1, 0, 10, 1, 0},
+		{99, 2, 1, 0, -1},
+		{1, 0, math.MaxInt64, 30, 1},
+		{-1, 0, math.MinInt64, 30, -1},
+		{0, 0, 0, 50, 0},
+		{0, math.MaxInt64, 0, math.MinInt64, 0},
+		{0, 0, 0, math.MaxInt64, 0},
+		{1, math.MaxInt64, 0, 0, 1},
+		{-1, math.MaxInt64, 0, math.MinInt64, -1},
+		{math.MaxInt64, 0, 1, -18, 1},
+		{math.MaxInt64, 0, 1, -19, -1},
+		{math.MinInt64, 0, -1, -18, -1},
+		{math.MinInt64, 0, -1, -19, 1},
+		{9, 0, 1, -1, -1},
+		{-922, 0, -1, -3, 1},
+	}
+	for _, tt := range tests {
+		if got := cmpDecimal(tt.ua, tt.sa, tt.ub, tt.sb); got != tt.want {
+			t.Errorf("cmpDecimal(%d, %d, %d, %d) = %d; want %d", tt.ua, tt.sa, tt.ub, tt.sb, got, tt.want)
+		}
+		if got := cmpDecimal(tt.ub, tt.sb, tt.ua, tt.sa); got != -tt.want {
+			t.Errorf("cmpDecimal(%d, %d, %d, %d) = %d; want %d", tt.ub, tt.sb, tt.ua, tt.sa, got, -tt.want)
+		}
+	}
+}
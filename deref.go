@@ -0,0 +1,221 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// DerefStable returns an Option for ordering slices of pointers, such
+// as []*T, by the values they point to, even if those values contain
+// fields that could otherwise only be ordered by machine address.
+//
+// With DerefStable, nil pointers order first, and the others order
+// by their pointees. Within the pointees, values of chan, func, map,
+// pointer and unsafe.Pointer kinds are skipped, as they would make
+// the order depend on where things happen to be in memory. Elements
+// that are equal on everything else are then ordered by the position
+// of each pointer's first appearance in the slice, so the result is a
+// deterministic total order. Elements holding the same pointer are
+// equal.
+//
+// Finding each pointer's first appearance requires a pass over the
+// slice and a map of its distinct pointers when the less function is
+// built.
+//
+// DerefStable only affects OfOpts, and OfOpts panics if the slice's
+// elements aren't pointers.
+func DerefStable() Option {
+	return func(c *config) {
+		c.derefStable = true
+		c.applied("DerefStable")
+	}
+}
+
+// lessDerefStable returns the less function for the slice of pointers
+// rv, as described by DerefStable.
+func (c *config) lessDerefStable(rv reflect.Value) less {
+	et := rv.Type().Elem()
+	if et.Kind() != reflect.Ptr {
+		return c.fail(errors.New("lesser: DerefStable used with non-pointer slice elements of type " + et.String()))
+	}
+	c.use("DerefStable")
+	rank := map[unsafe.Pointer]int{}
+	for i := 0; i < rv.Len(); i++ {
+		p := unsafe.Pointer(rv.Index(i).Pointer())
+		if _, ok := rank[p]; !ok {
+			rank[p] = len(rank)
+		}
+	}
+	// The pointees' tie-breaker is given their addresses, which are
+	// the pointers themselves.
+	pointee := c.forAddr(0, et.Elem(), "", func(a, b unsafe.Pointer) bool {
+		return rank[a] < rank[b]
+	})
+	return func(a, b unsafe.Pointer) bool {
+		pa, pb := *(*unsafe.Pointer)(a), *(*unsafe.Pointer)(b)
+		if pa == nil || pb == nil {
+			return pa == nil && pb != nil
+		}
+		return pointee(pa, pb)
+	}
+}
+
+// DerefScalars returns an Option that orders pointers to scalars, such
+// as the *int, *string and *time.Time fields often used for nullable
+// database columns, by the values they point to rather than by
+// address. Nil pointers order first, or last with NilsLast, and equal
+// to each other. Scalars are booleans, numbers, strings and
+// time.Time values, and named types of those kinds; the pointees are
+// ordered as if they weren't behind pointers, including by any
+// options that apply to them.
+//
+// Pointer types with their own ordering, and pointers to other
+// types, are unaffected.
+func DerefScalars() Option {
+	return func(c *config) {
+		c.derefScalars = true
+		c.applied("DerefScalars")
+	}
+}
+
+// Deref returns an Option that orders all pointers by the values they
+// point to, as DerefScalars does for pointers to scalars: nil pointers
+// first, or last with NilsLast, and the others by their pointees,
+// ordered as if they weren't behind pointers. Pointees' own pointers
+// are followed in turn, so the pointers of a linked list are followed
+// to its end. The values reached may form cycles, as in a circular
+// list: a pair of pointers reached again while already comparing them
+// compares equal there, so the comparison ends, much as with
+// reflect.DeepEqual.
+//
+// Pointer types with their own ordering are unaffected. Without
+// Deref, pointers order by address, which is rarely meaningful and
+// can vary from run to run.
+func Deref() Option {
+	return func(c *config) {
+		c.deref = true
+		c.applied("Deref")
+	}
+}
+
+// NilsLast returns an Option that makes the pointers compared by
+// DerefScalars or Deref order nil pointers after all others, as with
+// SQL's NULLS LAST. It likewise orders NULL values of database/sql's
+// nullable types, such as sql.NullInt64, last.
+func NilsLast() Option {
+	return func(c *config) {
+		c.nilsLast = true
+		c.applied("NilsLast")
+	}
+}
+
+// isScalar reports whether t is a scalar type, as described by
+// DerefScalars.
+func isScalar(t reflect.Type) bool {
+	switch k := t.Kind(); {
+	case k == reflect.Bool, k == reflect.String, isInt(k), isUint(k),
+		k == reflect.Float32, k == reflect.Float64:
+		return true
+	}
+	return t == timeType
+}
+
+// lessDeref returns the leaf for the pointer type t at path, for
+// DerefScalars and Deref, comparing pointees by their ordering under
+// c, with nil pointers first or, if nilsLast is set, last. Equal
+// pointers have equal pointees.
+//
+// The outermost pointer type being dereferenced gets a derefGuard,
+// shared by the pointer types reached from it.
+func (c *config) lessDeref(t reflect.Type, path string, nilsLast bool) func(off uintptr, optEq less) less {
+	g, outer := c.derefGuard, c.derefGuard == nil
+	if outer {
+		g = new(derefGuard)
+		c.derefGuard = g
+	}
+	pointee := c.elemLess(t, func() less { return c.forType(0, t.Elem(), path, nil) })
+	if outer {
+		c.derefGuard = nil
+	}
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			pa, pb := *(*unsafe.Pointer)(at(a, off)), *(*unsafe.Pointer)(at(b, off))
+			if pa == nil || pb == nil {
+				if (pa == nil) != (pb == nil) {
+					return (pa == nil) != nilsLast
+				}
+			} else if pa != pb {
+				if c := g.cmp(*pointee, pa, pb, outer, optEq == nil); c != 0 {
+					return c < 0
+				}
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// untrackedDepth is the number of pointers a comparison follows before
+// derefGuard starts watching for cycles, so that comparisons that end
+// sooner don't pay for it.
+const untrackedDepth = 16
+
+// A derefGuard keeps comparisons of recursive types under Deref, such
+// as linked lists, from following a cycle of pointers forever.
+type derefGuard struct {
+	// recursive is set while building if the ordering refers back
+	// to itself. Otherwise there can't be cycles, and the fields
+	// below are unused.
+	recursive bool
+
+	mu    sync.Mutex // held by the outermost pointer comparison
+	depth int        // pointers followed
+	path  map[[2]unsafe.Pointer]bool
+}
+
+// cmp compares the values at pa and pb with less, returning -1, 0 or
+// +1. If ltOnly is set, the caller only needs to know whether the
+// result is -1, and cmp returns +1 instead of 0, sparing a call to
+// less that would otherwise make comparing long lists take time
+// exponential in their length.
+//
+// The outer comparison is that of the outermost pointer type, and the
+// others happen within it. A pair of pointers that's already being
+// compared further up the same outer comparison is a cycle, and
+// compares equal.
+func (g *derefGuard) cmp(less less, pa, pb unsafe.Pointer, outer, ltOnly bool) int {
+	if g.recursive {
+		if outer {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+		}
+		g.depth++
+		defer func() { g.depth-- }()
+		if g.depth > untrackedDepth {
+			k := [2]unsafe.Pointer{pa, pb}
+			if g.path[k] {
+				return 0
+			}
+			if g.path == nil {
+				g.path = make(map[[2]unsafe.Pointer]bool)
+			}
+			g.path[k] = true
+			defer delete(g.path, k)
+		}
+	}
+	switch {
+	case less(pa, pb):
+		return -1
+	case ltOnly, less(pb, pa):
+		return 1
+	}
+	return 0
+}
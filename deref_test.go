@@ -0,0 +1,285 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type withChan struct {
+	N  int
+	Ch chan int
+	ID string // not compared; identifies the element in tests
+}
+
+func TestDerefStable(t *testing.T) {
+	// Allocate so that memory order disagrees with slice order.
+	c := &withChan{1, make(chan int), "c"}
+	b := &withChan{0, make(chan int), "b"}
+	a := &withChan{1, make(chan int), "a"}
+	d := &withChan{1, make(chan int), "d"}
+
+	in := []*withChan{a, b, nil, c, a, d}
+	ids := func(s []*withChan) (ret []string) {
+		for _, p := range s {
+			if p == nil {
+				ret = append(ret, "nil")
+			} else {
+				ret = append(ret, p.ID)
+			}
+		}
+		return ret
+	}
+
+	for i := 0; i < 3; i++ {
+		clone := append([]*withChan(nil), in...)
+		sort.Slice(clone, OfOpts(clone, DerefStable()))
+		got := ids(clone)
+		want := []string{"nil", "b", "a", "a", "c", "d"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("iteration %d: got %q; want %q", i, got, want)
+		}
+	}
+}
+
+func TestDerefStableTieByFirstSeen(t *testing.T) {
+	type node struct {
+		N  int
+		Ch chan int
+	}
+	x, y, z := &node{1, make(chan int)}, &node{1, make(chan int)}, &node{0, nil}
+	for _, in := range [][]*node{{x, y, z}, {y, x, z}} {
+		got := append([]*node(nil), in...)
+		sort.Slice(got, OfOpts(got, DerefStable()))
+		want := []*node{z, in[0], in[1]}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("sorting %p: got %p; want %p", in, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestDerefScalars(t *testing.T) {
+	type row struct {
+		Age  *int
+		Name string
+	}
+	n := func(v int) *int { return &v }
+	in := []row{{n(30), "a"}, {nil, "b"}, {n(4), "c"}, {n(30), "d"}, {nil, "e"}, {n(-1), "f"}}
+	names := func(s []row) (ret string) {
+		for _, r := range s {
+			ret += r.Name
+		}
+		return ret
+	}
+	sort.Slice(in, OfOpts(in, DerefScalars()))
+	if got, want := names(in), "befcad"; got != want {
+		t.Errorf("nils first: got %s; want %s", got, want)
+	}
+	sort.Slice(in, OfOpts(in, DerefScalars(), NilsLast()))
+	if got, want := names(in), "fcadbe"; got != want {
+		t.Errorf("nils last: got %s; want %s", got, want)
+	}
+
+	type label struct{ S *string }
+	s := func(v string) *string { return &v }
+	labels := []label{{s("b")}, {s("A")}, {nil}, {s("a")}}
+	sort.Slice(labels, OfOpts(labels, DerefScalars(), Fold()))
+	var got []string
+	for _, l := range labels {
+		if l.S == nil {
+			got = append(got, "nil")
+		} else {
+			got = append(got, *l.S)
+		}
+	}
+	if want := []string{"nil", "A", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with Fold: got %q; want %q", got, want)
+	}
+
+	later, earlier := time.Unix(2, 0), time.Unix(1, 0)
+	times := []*time.Time{&later, nil, &earlier}
+	sort.Slice(times, OfOpts(times, DerefScalars()))
+	if times[0] != nil || times[1] != &earlier || times[2] != &later {
+		t.Errorf("times not in order: %v", times)
+	}
+
+	if err := ValidateOpts(reflect.TypeOf(withChan{}), DerefScalars()); err == nil {
+		t.Error("DerefScalars without pointers to scalars: got nil error")
+	}
+}
+
+func TestDeref(t *testing.T) {
+	n := func(v int) *int { return &v }
+	ints := []*int{n(3), nil, n(1), nil, n(2)}
+	sort.Slice(ints, OfOpts(ints, Deref()))
+	if ints[0] != nil || ints[1] != nil || *ints[2] != 1 || *ints[3] != 2 || *ints[4] != 3 {
+		t.Errorf("[]*int not in order")
+	}
+
+	type labeled struct {
+		Label *string
+		N     int
+	}
+	s := func(v string) *string { return &v }
+	in := []labeled{{s("b"), 1}, {nil, 2}, {s("a"), 2}, {s("b"), 0}, {nil, 1}}
+	sort.Slice(in, OfOpts(in, Deref()))
+	var got []string
+	for _, l := range in {
+		label := "nil"
+		if l.Label != nil {
+			label = *l.Label
+		}
+		got = append(got, fmt.Sprintf("%s%d", label, l.N))
+	}
+	if want := []string{"nil1", "nil2", "a2", "b0", "b1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	type list struct {
+		V    int
+		Next *list
+	}
+	l := func(vs ...int) *list {
+		var head *list
+		for i := len(vs) - 1; i >= 0; i-- {
+			head = &list{vs[i], head}
+		}
+		return head
+	}
+	lists := []*list{l(1, 2, 3), l(1, 2), l(0, 9), l(1, 2, 2)}
+	str := func(lists []*list) (ret []string) {
+		for _, p := range lists {
+			var s string
+			for ; p != nil; p = p.Next {
+				s += fmt.Sprint(p.V)
+			}
+			ret = append(ret, s)
+		}
+		return ret
+	}
+	sort.Slice(lists, OfOpts(lists, Deref()))
+	if got, want := str(lists), []string{"09", "12", "122", "123"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lists: got %q; want %q", got, want)
+	}
+	// With NilsLast, a list's end orders after any next element.
+	sort.Slice(lists, OfOpts(lists, Deref(), NilsLast()))
+	if got, want := str(lists), []string{"09", "122", "123", "12"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lists with NilsLast: got %q; want %q", got, want)
+	}
+}
+
+func TestDerefStructElements(t *testing.T) {
+	type user struct {
+		Name    string
+		Manager *user
+	}
+	boss := &user{Name: "boss"}
+	in := []*user{
+		{Name: "carol", Manager: boss},
+		nil,
+		{Name: "alice", Manager: boss},
+		{Name: "carol"},
+		boss,
+	}
+	sort.Slice(in, OfOpts(in, Deref()))
+	var got []string
+	for _, u := range in {
+		switch {
+		case u == nil:
+			got = append(got, "nil")
+		case u.Manager == nil:
+			got = append(got, u.Name)
+		default:
+			got = append(got, u.Name+"<"+u.Manager.Name)
+		}
+	}
+	if want := []string{"nil", "alice<boss", "boss", "carol", "carol<boss"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestDerefCycles(t *testing.T) {
+	type ring struct {
+		V    int
+		Next *ring
+	}
+	// newRing returns a circular list of vs.
+	newRing := func(vs ...int) *ring {
+		head := &ring{V: vs[0]}
+		p := head
+		for _, v := range vs[1:] {
+			p.Next = &ring{V: v}
+			p = p.Next
+		}
+		p.Next = head
+		return p.Next
+	}
+	self := &ring{V: 1}
+	self.Next = self
+	in := []*ring{newRing(1, 2), newRing(1, 1, 1), self, newRing(1, 2, 1, 3), newRing(1, 2, 1, 2), newRing(0)}
+	want := []*ring{in[5], in[1], in[2], in[0], in[4], in[3]}
+	sort.Slice(in, OfOpts(in, Deref()))
+	for i := range in {
+		// Unfolded, in[1], in[2] and in[0], in[4] are the
+		// same infinite lists, so either order is fine.
+		if in[i].V != want[i].V || in[i].Next.V != want[i].Next.V || in[i].Next.Next.Next.V != want[i].Next.Next.Next.V {
+			t.Errorf("in[%d] = %d, %d, ...; want %d, %d, ...", i, in[i].V, in[i].Next.V, want[i].V, want[i].Next.V)
+		}
+	}
+
+	// Comparisons of long lists take linear time, and may run
+	// concurrently.
+	long := func(n int) *ring {
+		vs := make([]int, n)
+		vs[n-1] = 1
+		return newRing(vs...)
+	}
+	a, b := long(10000), long(10001)
+	less := OfOpts([]*ring{a, b}, Deref())
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func() {
+			done <- less(1, 0) && !less(0, 1)
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if !<-done {
+			t.Error("longer run of zeros should order first")
+		}
+	}
+}
+
+func TestDerefCyclesThroughInterfaces(t *testing.T) {
+	type node struct {
+		N int
+		V interface{}
+	}
+	a, b, c := &node{N: 1}, &node{N: 1}, &node{N: 1}
+	a.V, b.V = a, b
+	c.V = &node{N: 2, V: c}
+	in := []*node{c, a, b}
+	less := OfOpts(in, Deref())
+	if less(1, 2) || less(2, 1) {
+		t.Error("self-referencing nodes with equal values should compare equal")
+	}
+	// c's cycle reaches N: 2 where a's reaches N: 1 again.
+	if !less(1, 0) || less(0, 1) {
+		t.Error("a should order before c")
+	}
+
+	// The same, reached through an interface first.
+	ifaces := []interface{}{c, a, b}
+	less = OfOpts(ifaces, Deref())
+	if less(1, 2) || less(2, 1) || !less(1, 0) || less(0, 1) {
+		t.Error("wrong order of nodes in interfaces")
+	}
+}
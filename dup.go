@@ -0,0 +1,110 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+)
+
+// HasDuplicates reports whether slice contains two elements that
+// compare equal under the ordering used by Of.
+//
+// The slice argument must be a slice. It is not modified.
+func HasDuplicates(slice interface{}) bool {
+	_, ok := FirstDuplicate(slice)
+	return ok
+}
+
+// FirstDuplicate returns the smallest index i such that slice[i]
+// compares equal to some earlier element of slice. If there are no
+// duplicates, it returns (-1, false).
+//
+// Two elements are equal if neither orders before the other under
+// Of. In particular, blank (_) struct fields are ignored and all
+// NaNs are equal to each other.
+//
+// The slice argument must be a slice. It is not modified; only a
+// permutation of its indices is sorted.
+func FirstDuplicate(slice interface{}) (int, bool) {
+	less := Of(slice)
+	idx := sortedIndices(reflect.ValueOf(slice).Len(), less)
+	dup := -1
+	for k := 1; k < len(idx); k++ {
+		// idx is sorted, so idx[k-1] is equal to idx[k]
+		// unless it orders strictly before it.
+		i, j := idx[k-1], idx[k]
+		if !less(i, j) && (dup == -1 || j < dup) {
+			dup = j
+		}
+	}
+	return dup, dup != -1
+}
+
+// GroupBy partitions the indices of slice into groups of elements
+// that compare equal under the ordering used by Of, as described by
+// FirstDuplicate.
+//
+// The groups are returned in sorted order of their elements, and the
+// indices within each group are in increasing order. GroupBy returns
+// nil for an empty slice.
+//
+// The slice argument must be a slice. It is not modified.
+func GroupBy(slice interface{}) [][]int {
+	less := Of(slice)
+	idx := sortedIndices(reflect.ValueOf(slice).Len(), less)
+	var groups [][]int
+	start := 0
+	for k := 1; k <= len(idx); k++ {
+		if k == len(idx) || less(idx[k-1], idx[k]) {
+			groups = append(groups, idx[start:k:k])
+			start = k
+		}
+	}
+	return groups
+}
+
+// sortedIndices returns the indices [0, n) stably sorted by less.
+// Equal elements therefore keep their indices in increasing order.
+func sortedIndices(n int, less func(i, j int) bool) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return less(idx[a], idx[b]) })
+	return idx
+}
+
+// SortUnique sorts slice in place using the ordering of Of, and moves
+// one element of each group of equal elements (see FirstDuplicate)
+// to the front, returning their number n. The sorted, unique elements
+// are then slice[:n]; the caller should reslice, as in
+//
+//	s = s[:lesser.SortUnique(s)]
+//
+// The element kept from each group is the first in the input, so
+// elements differing only in blank fields keep the earliest. The
+// contents of slice[n:] are unspecified.
+//
+// The slice argument must be a slice.
+func SortUnique(slice interface{}) int {
+	StableSort(slice)
+	rv := reflect.ValueOf(slice)
+	if rv.Len() < 2 {
+		return rv.Len()
+	}
+	less := Of(slice)
+	n := 1
+	for i := 1; i < rv.Len(); i++ {
+		if !less(n-1, i) {
+			continue // equal to the last kept element
+		}
+		if n != i {
+			rv.Index(n).Set(rv.Index(i))
+		}
+		n++
+	}
+	return n
+}
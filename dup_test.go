@@ -0,0 +1,120 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestFirstDuplicate(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		in     interface{}
+		want   int
+		wantOK bool
+	}{
+		{"empty", []int{}, -1, false},
+		{"unique", []int{3, 1, 2}, -1, false},
+		{"int", []int{3, 1, 4, 1, 5, 3}, 3, true},
+		{"string", []string{"b", "a", "c", "b"}, 3, true},
+		{"nan", []float64{nan, 1, nan}, 2, true},
+		{"struct", []TStringInt{{"a", 1}, {"a", 2}, {"b", 1}, {"a", 2}}, 3, true},
+		{"struct_unique", []TStringInt{{"a", 1}, {"a", 2}, {"b", 1}}, -1, false},
+		{"blank_ignored", []blankStruct{{1, 2, 3}, {1, 5, 3}}, 1, true},
+		{"blank_real_field", []blankStruct{{1, 2, 3}, {1, 2, 4}}, -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FirstDuplicate(tt.in)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("FirstDuplicate = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if has := HasDuplicates(tt.in); has != tt.wantOK {
+				t.Errorf("HasDuplicates = %v; want %v", has, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFirstDuplicateDoesNotModify(t *testing.T) {
+	s := []int{3, 1, 2, 1}
+	FirstDuplicate(s)
+	if s[0] != 3 || s[1] != 1 || s[2] != 2 || s[3] != 1 {
+		t.Errorf("slice modified: %v", s)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want [][]int
+	}{
+		{"empty", []int{}, nil},
+		{"int", []int{3, 1, 4, 1, 3}, [][]int{{1, 3}, {0, 4}, {2}}},
+		{"struct", []TStringInt{{"b", 1}, {"a", 2}, {"b", 1}}, [][]int{{1}, {0, 2}}},
+		{"blank", []blankStruct{{1, 2, 3}, {0, 0, 0}, {1, 5, 3}}, [][]int{{1}, {0, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GroupBy(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GroupBy = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortUnique(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{"empty", []int{}, []int{}},
+		{"one", []int{1}, []int{1}},
+		{"int", []int{3, 1, 4, 1, 5, 3}, []int{1, 3, 4, 5}},
+		{"nan", []float64{2, nan, 1, nan, 2}, []float64{nan, 1, 2}},
+		// Differing only in the blank field collapses, keeping the
+		// first; differing in a real field doesn't.
+		{"blank", []blankStruct{{1, 5, 3}, {0, 0, 0}, {1, 2, 3}, {1, 2, 4}},
+			[]blankStruct{{0, 0, 0}, {1, 5, 3}, {1, 2, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := SortUnique(tt.in)
+			got := reflect.ValueOf(tt.in).Slice(0, n).Interface()
+			if tt.name == "nan" {
+				// NaN != NaN, so DeepEqual can't compare them.
+				g := got.([]float64)
+				if len(g) != 3 || !math.IsNaN(g[0]) || g[1] != 1 || g[2] != 2 {
+					t.Errorf("got %v; want %v", got, tt.want)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortUniqueKeepsFirstBlank(t *testing.T) {
+	s := []blankStruct{{1, 0, 3}, {0, 0, 0}, {1, 0, 3}}
+	setBlank(&s[0], 5)
+	setBlank(&s[2], 2)
+	s = s[:SortUnique(s)]
+	if len(s) != 2 {
+		t.Fatalf("got %d elements; want 2", len(s))
+	}
+	if got := (*[3]int)(unsafe.Pointer(&s[1]))[1]; got != 5 {
+		t.Errorf("kept element with blank %d; want the first, with 5", got)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func init() {
+	// Errors are mostly pointers, which would otherwise order by
+	// address within each dynamic type. Their messages are what
+	// people read, and stable from run to run.
+	register(errorType, cmpError)
+}
+
+// cmpError compares two error values: nil first, then errors holding
+// nil pointers by the names of their dynamic types, as for
+// cmpIfaceMethod, then the rest by their messages.
+func cmpError(a, b unsafe.Pointer) int {
+	va, vb := reflect.NewAt(errorType, a).Elem(), reflect.NewAt(errorType, b).Elem()
+	ra, rb := nilRank(va), nilRank(vb)
+	switch {
+	case ra != rb:
+		return ra - rb
+	case ra == ifaceNil:
+		return 0
+	case ra == ifaceNilValue:
+		return strings.Compare(va.Elem().Type().String(), vb.Elem().Type().String())
+	}
+	return strings.Compare((*(*error)(a)).Error(), (*(*error)(b)).Error())
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+)
+
+type codeError struct{ code int }
+
+func (e *codeError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestErrors(t *testing.T) {
+	in := []error{
+		errors.New("zebra"),
+		nil,
+		&codeError{2},
+		(*codeError)(nil),
+		os.ErrNotExist,
+		errors.New("apple"),
+		nil,
+		fmt.Errorf("wrapped: %w", os.ErrNotExist),
+	}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, err := range in {
+		switch e := err.(type) {
+		case nil:
+			got = append(got, "nil")
+		case *codeError:
+			if e == nil {
+				got = append(got, "nil *codeError")
+				continue
+			}
+			got = append(got, e.Error())
+		default:
+			got = append(got, e.Error())
+		}
+	}
+	want := fmt.Sprint([]string{"nil", "nil", "nil *codeError", "apple", "code 2", "file does not exist", "wrapped: file does not exist", "zebra"})
+	if fmt.Sprint(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	type result struct {
+		Err error
+		N   int
+	}
+	rs := []result{
+		{errors.New("b"), 0},
+		{nil, 1},
+		{errors.New("a"), 2},
+		{errors.New("a"), 3},
+	}
+	sort.SliceStable(rs, Of(rs))
+	var order []int
+	for _, r := range rs {
+		order = append(order, r.N)
+	}
+	if fmt.Sprint(order) != "[1 2 3 0]" {
+		t.Errorf("struct order = %v; want [1 2 3 0]", order)
+	}
+}
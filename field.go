@@ -0,0 +1,252 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// A fieldRule changes how the struct field at one path is compared.
+//
+// Options that take a field argument name the field by its path: the
+// names of the struct fields leading to it from the slice element,
+// joined by dots, such as "Start" or "Span.Start". Embedded fields
+// are named by their type name, as in reflect.
+type fieldRule struct {
+	name string // option name, for ValidateOpts
+
+	// build returns a less function for the value of type t at the
+	// rule's path, or nil if the rule doesn't apply to t. See
+	// config.forAddr for the other arguments. To compare by the
+	// field's default ordering, build may call c.forType.
+	build func(c *config, off uintptr, t reflect.Type, path string, optEq less) less
+}
+
+// setField installs rule for the field path. The args are those of the
+// option, as for config.applied.
+func (c *config) setField(path string, rule fieldRule, args ...interface{}) {
+	if c.fields == nil {
+		c.fields = make(map[string]fieldRule)
+	}
+	c.fields[path] = rule
+	c.applied(rule.name, args...)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// Modulo returns an Option that orders the integer field at path by
+// its value modulo n first, and only then by its value. This groups
+// values into n buckets, such as Modulo("Minutes", 60) ordering by
+// minute of the hour.
+//
+// The remainder is computed with Go's % operator, which for a signed
+// field has the sign of the value: with n 10, -3 orders before 2,
+// since -3 % 10 is -3.
+//
+// If the field is an array of integers, each element is compared this
+// way. Modulo panics if n is not positive.
+func Modulo(path string, n int64) Option {
+	if n <= 0 {
+		panic("lesser: Modulo requires a positive modulus")
+	}
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("Modulo(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				k := t.Kind()
+				if !isInt(k) && !isUint(k) {
+					return nil
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					pa, pb := at(a, off), at(b, off)
+					if isInt(k) {
+						if ma, mb := readInt(k, pa)%n, readInt(k, pb)%n; ma != mb {
+							return ma < mb
+						}
+					} else {
+						if ma, mb := readUint(k, pa)%uint64(n), readUint(k, pb)%uint64(n); ma != mb {
+							return ma < mb
+						}
+					}
+					return next(a, b)
+				}
+			},
+		}, n)
+	}
+}
+
+// PinFirst returns an Option that orders the field at path so that
+// the values in values come first, in their order in values, followed
+// by all other values in their usual order. When the field is the
+// first to be compared, this gives the common "pin favorites to the
+// top" ordering, as in PinFirst("Team", []interface{}{"infra"}).
+//
+// Each value should have the field's type. Integer values may also be
+// of another integer type, as with untyped constants, if they fit. A
+// nil value matches a nil pointer or interface. Other values, and
+// values that aren't comparable, match nothing.
+//
+// PinFirst applies to fields of comparable types. Looking up a
+// field's value costs an allocation per comparison for most types.
+func PinFirst(path string, values []interface{}) Option {
+	values = append([]interface{}(nil), values...)
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("PinFirst(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				if !t.Comparable() {
+					return nil
+				}
+				rank := pinRanks(t, values)
+				unpinned := len(values)
+				rankAt := func(p unsafe.Pointer) int {
+					v := reflect.NewAt(t, p).Elem()
+					if t.Kind() == reflect.Interface && !v.IsNil() && !v.Elem().Type().Comparable() {
+						return unpinned
+					}
+					if r, ok := rank[v.Interface()]; ok {
+						return r
+					}
+					return unpinned
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					if ra, rb := rankAt(at(a, off)), rankAt(at(b, off)); ra != rb {
+						return ra < rb
+					}
+					return next(a, b)
+				}
+			},
+		}, values)
+	}
+}
+
+// pinRanks returns the position of each of values in values, keyed
+// by its value as type t, as described by PinFirst.
+func pinRanks(t reflect.Type, values []interface{}) map[interface{}]int {
+	rank := make(map[interface{}]int)
+	for i, v := range values {
+		rv := reflect.ValueOf(v)
+		switch k := t.Kind(); {
+		case !rv.IsValid():
+			if k != reflect.Ptr && k != reflect.Interface && k != reflect.Chan &&
+				k != reflect.Func && k != reflect.Map && k != reflect.UnsafePointer {
+				continue
+			}
+			rv = reflect.Zero(t)
+		case !rv.Type().Comparable():
+			continue
+		case rv.Type() == t:
+		case k == reflect.Interface && rv.Type().Implements(t):
+		case (isInt(k) || isUint(k)) && (isInt(rv.Kind()) || isUint(rv.Kind())):
+			cv := rv.Convert(t)
+			if cv.Convert(rv.Type()).Interface() != v {
+				continue // doesn't fit
+			}
+			rv = cv
+		default:
+			continue
+		}
+		key := rv.Interface()
+		if _, dup := rank[key]; !dup {
+			rank[key] = i
+		}
+	}
+	return rank
+}
+
+func isInt(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// readInt reads the signed integer of kind k at p.
+func readInt(k reflect.Kind, p unsafe.Pointer) int64 {
+	switch k {
+	case reflect.Int:
+		return int64(*(*int)(p))
+	case reflect.Int8:
+		return int64(*(*int8)(p))
+	case reflect.Int16:
+		return int64(*(*int16)(p))
+	case reflect.Int32:
+		return int64(*(*int32)(p))
+	case reflect.Int64:
+		return *(*int64)(p)
+	}
+	panic("unreachable")
+}
+
+// readUint reads the unsigned integer of kind k at p.
+func readUint(k reflect.Kind, p unsafe.Pointer) uint64 {
+	switch k {
+	case reflect.Uint:
+		return uint64(*(*uint)(p))
+	case reflect.Uint8:
+		return uint64(*(*uint8)(p))
+	case reflect.Uint16:
+		return uint64(*(*uint16)(p))
+	case reflect.Uint32:
+		return uint64(*(*uint32)(p))
+	case reflect.Uint64:
+		return *(*uint64)(p)
+	case reflect.Uintptr:
+		return uint64(*(*uintptr)(p))
+	}
+	panic("unreachable")
+}
+
+// fieldByPath returns the struct field of t named by path, as
+// described for fieldRule, with its Offset relative to t. The empty
+// path names a value of type t itself.
+func fieldByPath(t reflect.Type, path string) (sf reflect.StructField, ok bool) {
+	if path == "" {
+		return reflect.StructField{Type: t}, true
+	}
+	var off uintptr
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() != reflect.Struct {
+			return sf, false
+		}
+		sf, ok = t.FieldByName(name)
+		if !ok || len(sf.Index) != 1 {
+			return sf, false
+		}
+		off += sf.Offset
+		t = sf.Type
+	}
+	sf.Offset = off
+	return sf, true
+}
+
+// mustFieldByPath is like fieldByPath, but panics if there's no such
+// field.
+func mustFieldByPath(t reflect.Type, path string) reflect.StructField {
+	sf, ok := fieldByPath(t, path)
+	if !ok {
+		panic(fmt.Sprintf("lesser: type %v has no field %q", t, path))
+	}
+	return sf
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type shard struct {
+	Name string
+	Seq  int
+}
+
+type shardRecord struct {
+	Shard shard
+	N     uint16
+}
+
+func TestModulo(t *testing.T) {
+	type seqName struct {
+		Seq  int
+		Name string
+	}
+	in := []seqName{{13, "a"}, {3, "b"}, {-3, "c"}, {20, "d"}, {10, "e"}, {7, "f"}}
+	sort.Slice(in, OfOpts(in, Modulo("Seq", 10)))
+	var got []string
+	for _, s := range in {
+		got = append(got, s.Name)
+	}
+	// By Seq%10 (-3, 0, 0, 3, 3, 7), then by Seq.
+	want := []string{"c", "e", "d", "b", "a", "f"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if err := ValidateOpts(reflect.TypeOf(shard{}), Modulo("Seq", 10)); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateOpts(reflect.TypeOf(shard{}), Modulo("Name", 10)); err == nil {
+		t.Error("Modulo on string field validated")
+	}
+}
+
+func TestModuloNested(t *testing.T) {
+	in := []shardRecord{{shard{"x", 1}, 5}, {shard{"x", 1}, 2}, {shard{"a", 0}, 9}, {shard{"a", 0}, 4}}
+	sort.Slice(in, OfOpts(in, Modulo("N", 3)))
+	want := []shardRecord{{shard{"a", 0}, 9}, {shard{"a", 0}, 4}, {shard{"x", 1}, 2}, {shard{"x", 1}, 5}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+
+	in2 := []shardRecord{{shard{"a", 12}, 0}, {shard{"a", 5}, 0}, {shard{"a", 1}, 0}}
+	sort.Slice(in2, OfOpts(in2, Modulo("Shard.Seq", 4)))
+	want2 := []shardRecord{{shard{"a", 12}, 0}, {shard{"a", 1}, 0}, {shard{"a", 5}, 0}}
+	if !reflect.DeepEqual(in2, want2) {
+		t.Errorf("got %v; want %v", in2, want2)
+	}
+}
+
+func TestNestedOffsets(t *testing.T) {
+	type inner struct {
+		A, B int
+	}
+	type outer struct {
+		X   int
+		In  inner
+		Arr [2]int8
+	}
+	in := []outer{
+		{1, inner{2, 3}, [2]int8{1, 1}},
+		{1, inner{2, 3}, [2]int8{0, 5}},
+		{1, inner{1, 9}, [2]int8{9, 9}},
+		{0, inner{5, 5}, [2]int8{5, 5}},
+	}
+	want := []outer{in[3], in[2], in[1], in[0]}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
+
+func TestPinFirst(t *testing.T) {
+	type row struct {
+		Team string
+		ID   uint8
+	}
+	in := []row{
+		{"web", 4},
+		{"infra", 3},
+		{"data", 2},
+		{"ml", 1},
+		{"infra", 0},
+		{"api", 5},
+	}
+	sort.Slice(in, OfOpts(in, PinFirst("Team", []interface{}{"ml", "infra", "nope"})))
+	var got []uint8
+	for _, r := range in {
+		got = append(got, r.ID)
+	}
+	if want := []uint8{1, 0, 3, 5, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("by Team: got %v; want %v", got, want)
+	}
+
+	// Untyped integer constants, one of which doesn't fit.
+	ids := []uint8{4, 1, 5, 3, 1}
+	sort.Slice(ids, OfOpts(ids, PinFirst("", []interface{}{3, 256 + 1, 1})))
+	if want := []uint8{3, 1, 1, 4, 5}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("by ID: got %v; want %v", ids, want)
+	}
+
+	owner := "kim"
+	owners := []*string{new(string), nil, &owner}
+	sort.Slice(owners, OfOpts(owners, PinFirst("", []interface{}{&owner, nil})))
+	if owners[0] != &owner || owners[1] != nil {
+		t.Errorf("pointers not pinned first: %v", owners)
+	}
+}
+
+func TestPinFirstInterface(t *testing.T) {
+	in := []interface{}{2, "b", []int{1}, nil, "a", 1}
+	sort.Slice(in, OfOpts(in, PinFirst("", []interface{}{"b", 1})))
+	if !reflect.DeepEqual(in[:2], []interface{}{"b", 1}) {
+		t.Errorf("got %v; want b, 1 first", in)
+	}
+	if in[2] != nil {
+		t.Errorf("got %v; want nil after pinned values", in)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"math/cmplx"
+	"unsafe"
+)
+
+// FloatEpsilon returns an Option that makes float32 and float64
+// values that are close together compare equal, so that the ordering
+// falls through to the next field.
+//
+// Rather than comparing |a-b| < eps, which isn't transitive (a may
+// be close to b and b close to c without a being close to c) and so
+// can't be used with sort, each value is first rounded to the nearest
+// multiple of eps. Values in the same grid cell are equal; all others
+// order as usual. As a consequence, two values closer than eps can
+// still compare unequal if they straddle the boundary between two
+// cells: with eps 0.1, 0.1+0.2 and 0.3 are equal, but 0.149 and
+// 0.151 are not.
+//
+// NaNs still order before all other values, or after them with
+// NaNLast. Complex numbers are not affected.
+//
+// FloatEpsilon panics if eps is not positive.
+func FloatEpsilon(eps float64) Option {
+	if !(eps > 0) {
+		panic("lesser: FloatEpsilon requires a positive epsilon")
+	}
+	return func(c *config) {
+		c.floatEps = eps
+		c.applied("FloatEpsilon", eps)
+	}
+}
+
+// OrderNaNPayloads returns an Option that orders distinct NaN values
+// of float32 and float64 types by their bit patterns, compared as
+// unsigned integers, rather than treating all NaNs as equal. NaNs
+// still order before all other values, or after them with NaNLast.
+//
+// Comparisons not involving two NaNs are unaffected and cost the
+// same. FloatEpsilon takes precedence over OrderNaNPayloads.
+func OrderNaNPayloads() Option {
+	return func(c *config) {
+		c.nanPayloads = true
+		c.applied("OrderNaNPayloads")
+	}
+}
+
+// NaNLast returns an Option that orders NaN values of float and
+// complex types after all other values, rather than before them. For
+// complex numbers, it applies to each of the real and imaginary parts.
+// Comparisons not involving a NaN are unaffected, and NaNs still
+// compare equal to each other, or by payload with OrderNaNPayloads.
+//
+// NaNLast composes with FloatEpsilon. With Desc, which reverses the
+// whole ordering, NaNs order first.
+func NaNLast() Option {
+	return func(c *config) {
+		c.nanLast = true
+		c.applied("NaNLast")
+	}
+}
+
+// lessNaNLast32 wraps makeLess, a float32 leaf, to order NaNs last.
+func lessNaNLast32(makeLess func(off uintptr, optEq less) less) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		next := makeLess(off, optEq)
+		return func(a, b unsafe.Pointer) bool {
+			if nanA, nanB := isNaN32(*(*float32)(at(a, off))), isNaN32(*(*float32)(at(b, off))); nanA != nanB {
+				return nanB
+			}
+			return next(a, b)
+		}
+	}
+}
+
+// lessNaNLast64 wraps makeLess, a float64 leaf, to order NaNs last.
+func lessNaNLast64(makeLess func(off uintptr, optEq less) less) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		next := makeLess(off, optEq)
+		return func(a, b unsafe.Pointer) bool {
+			if nanA, nanB := math.IsNaN(*(*float64)(at(a, off))), math.IsNaN(*(*float64)(at(b, off))); nanA != nanB {
+				return nanB
+			}
+			return next(a, b)
+		}
+	}
+}
+
+// FloatTotalOrder returns an Option that orders float and complex
+// values by the totalOrder predicate of IEEE 754, which tells apart
+// every bit pattern: negative NaNs first, then -Inf, the negative
+// numbers, -0, +0, the positive numbers, +Inf and finally positive
+// NaNs, with NaNs of the same sign ordered by payload. Only values
+// with identical bits are equal, so the result doesn't depend on how
+// a sort happens to arrange them.
+//
+// Complex numbers are ordered by real part, then imaginary part, each
+// in total order. FloatTotalOrder takes precedence over the other
+// float options.
+func FloatTotalOrder() Option {
+	return func(c *config) {
+		c.totalOrder = true
+		c.applied("FloatTotalOrder")
+	}
+}
+
+// totalKey32 and totalKey64 map float bits to unsigned integers that
+// order as FloatTotalOrder describes: negative values have all their
+// bits flipped, so larger magnitudes order first, and non-negative
+// ones just their sign bit, so they order after all negative values.
+func totalKey32(f float32) uint32 {
+	b := math.Float32bits(f)
+	if b>>31 != 0 {
+		return ^b
+	}
+	return b | 1<<31
+}
+
+func totalKey64(f float64) uint64 {
+	b := math.Float64bits(f)
+	if b>>63 != 0 {
+		return ^b
+	}
+	return b | 1<<63
+}
+
+func lessFloat32Total(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		ka, kb := totalKey32(*(*float32)(at(a, off))), totalKey32(*(*float32)(at(b, off)))
+		if ka == kb {
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+		return ka < kb
+	}
+}
+
+func lessFloat64Total(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		ka, kb := totalKey64(*(*float64)(at(a, off))), totalKey64(*(*float64)(at(b, off)))
+		if ka == kb {
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+		return ka < kb
+	}
+}
+
+// ComplexByMagnitude returns an Option that orders complex64 and
+// complex128 values by their magnitude (absolute value), and values
+// of equal magnitude by their phase, from -Pi to Pi, rather than by
+// real and then imaginary part. Magnitudes are those of cmplx.Abs:
+// values with an infinite part have an infinite magnitude, even if
+// the other part is NaN, and other values with a NaN part have a NaN
+// magnitude. NaN magnitudes, and then NaN phases among values of
+// equal magnitude, order before all others, or after them with
+// NaNLast.
+//
+// ComplexByMagnitude takes precedence over FloatTotalOrder for
+// complex values.
+func ComplexByMagnitude() Option {
+	return func(c *config) {
+		c.complexMag = true
+		c.applied("ComplexByMagnitude")
+	}
+}
+
+// lessComplexMagnitude returns the complex leaf for ComplexByMagnitude,
+// with read loading a value of the complex type as a complex128.
+func lessComplexMagnitude(read func(p unsafe.Pointer) complex128, nanLast bool) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := read(at(a, off)), read(at(b, off))
+			if c := cmpFloat64(cmplx.Abs(va), cmplx.Abs(vb), nanLast); c != 0 {
+				return c < 0
+			}
+			if c := cmpFloat64(cmplx.Phase(va), cmplx.Phase(vb), nanLast); c != 0 {
+				return c < 0
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// cmpFloat64 compares a and b like the default float ordering, with
+// NaNs equal to each other and before all other values, or after them
+// if nanLast is set.
+func cmpFloat64(a, b float64, nanLast bool) int {
+	nanA, nanB := math.IsNaN(a), math.IsNaN(b)
+	switch {
+	case nanA || nanB:
+		if nanA == nanB {
+			return 0
+		}
+		if nanA != nanLast {
+			return -1
+		}
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func lessFloat32Payload(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*float32)(at(a, off)), *(*float32)(at(b, off))
+		if va == vb {
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+		if isNaN32(va) && isNaN32(vb) {
+			if ba, bb := math.Float32bits(va), math.Float32bits(vb); ba != bb {
+				return ba < bb
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+		return va < vb || isNaN32(va)
+	}
+}
+
+func lessFloat64Payload(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*float64)(at(a, off)), *(*float64)(at(b, off))
+		if va == vb {
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+		if math.IsNaN(va) && math.IsNaN(vb) {
+			if ba, bb := math.Float64bits(va), math.Float64bits(vb); ba != bb {
+				return ba < bb
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+		return va < vb || math.IsNaN(va)
+	}
+}
+
+func lessFloat32Grid(eps float64) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := *(*float32)(at(a, off)), *(*float32)(at(b, off))
+			return lessGrid(float64(va), float64(vb), eps, optEq, a, b)
+		}
+	}
+}
+
+func lessFloat64Grid(eps float64) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := *(*float64)(at(a, off)), *(*float64)(at(b, off))
+			return lessGrid(va, vb, eps, optEq, a, b)
+		}
+	}
+}
+
+func lessGrid(va, vb, eps float64, optEq less, a, b unsafe.Pointer) bool {
+	va, vb = math.Round(va/eps), math.Round(vb/eps)
+	nanA, nanB := math.IsNaN(va), math.IsNaN(vb)
+	if va == vb || nanA && nanB {
+		if optEq != nil {
+			return optEq(a, b)
+		}
+		return false
+	}
+	return va < vb || nanA
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type measurement struct {
+	V    float64
+	Name string
+}
+
+func TestFloatEpsilon(t *testing.T) {
+	nan := math.NaN()
+	in := []measurement{
+		{0.3, "b"},
+		{0.1 + 0.2, "a"},
+		{nan, "z"},
+		{0.2, "c"},
+		{nan, "y"},
+		{-1, "d"},
+	}
+	sort.Slice(in, OfOpts(in, FloatEpsilon(0.01)))
+	var got []string
+	for _, m := range in {
+		got = append(got, m.Name)
+	}
+	want := []string{"y", "z", "d", "c", "a", "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestFloatEpsilonFloat32(t *testing.T) {
+	type m32 struct {
+		V float32
+		N int
+	}
+	in := []m32{{1.04, 2}, {1.01, 1}, {0.5, 3}}
+	sort.Slice(in, OfOpts(in, FloatEpsilon(0.1)))
+	want := []m32{{0.5, 3}, {1.01, 1}, {1.04, 2}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
+
+func TestNaNTieBreak(t *testing.T) {
+	nan := math.NaN()
+	in := []measurement{{nan, "c"}, {1, "a"}, {nan, "b"}, {nan, "a"}}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, m := range in {
+		got = append(got, m.Name)
+	}
+	// NaNs are equal, so they order by Name.
+	if want := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestOrderNaNPayloads(t *testing.T) {
+	nan1 := math.Float64frombits(0x7ff8000000000001)
+	nan2 := math.Float64frombits(0x7ff8000000000002)
+	nan3 := math.Float64frombits(0xfff8000000000000)
+	in := []measurement{{nan3, "x"}, {2, "y"}, {nan2, "z"}, {nan1, "w"}, {nan2, "a"}, {-1, "v"}}
+	sort.Slice(in, OfOpts(in, OrderNaNPayloads()))
+	var got []string
+	for _, m := range in {
+		got = append(got, m.Name)
+	}
+	want := []string{"w", "a", "z", "x", "v", "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	f32 := []float32{
+		math.Float32frombits(0x7fc00002),
+		1,
+		math.Float32frombits(0x7fc00001),
+	}
+	sort.Slice(f32, OfOpts(f32, OrderNaNPayloads()))
+	if math.Float32bits(f32[0]) != 0x7fc00001 || math.Float32bits(f32[1]) != 0x7fc00002 || f32[2] != 1 {
+		t.Errorf("float32: got %x", []uint32{math.Float32bits(f32[0]), math.Float32bits(f32[1]), math.Float32bits(f32[2])})
+	}
+}
+
+func TestNaNLast(t *testing.T) {
+	nan := math.NaN()
+	in := []measurement{{nan, "b"}, {2, "y"}, {math.Inf(1), "z"}, {nan, "a"}, {-1, "x"}}
+	names := func() (ret []string) {
+		for _, m := range in {
+			ret = append(ret, m.Name)
+		}
+		return ret
+	}
+	sort.Slice(in, OfOpts(in, NaNLast()))
+	if got, want := names(), []string{"x", "y", "z", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	in = append(in, measurement{1.99, "w"})
+	sort.Slice(in, OfOpts(in, NaNLast(), FloatEpsilon(0.1)))
+	if got, want := names(), []string{"x", "w", "y", "z", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with FloatEpsilon: got %v; want %v", got, want)
+	}
+
+	f32 := []float32{float32(nan), 1, -1}
+	sort.Slice(f32, OfOpts(f32, NaNLast()))
+	if f32[0] != -1 || f32[1] != 1 || !isNaN32(f32[2]) {
+		t.Errorf("float32: got %v", f32)
+	}
+
+	c128 := []complex128{complex(nan, 0), complex(1, nan), complex(1, 2), complex(-1, 0)}
+	sort.Slice(c128, OfOpts(c128, NaNLast()))
+	if c128[0] != complex(-1, 0) || c128[1] != complex(1, 2) || !math.IsNaN(imag(c128[2])) || !math.IsNaN(real(c128[3])) {
+		t.Errorf("complex128: got %v", c128)
+	}
+}
+
+func TestFloatTotalOrder(t *testing.T) {
+	negNaN := math.Float64frombits(0xfff8000000000000)
+	nan1 := math.Float64frombits(0x7ff8000000000001)
+	sNaN := math.Float64frombits(0x7ff0000000000001)
+	negZero := math.Copysign(0, -1)
+	in := []float64{nan1, 0, math.Inf(1), negZero, sNaN, -2, math.Inf(-1), negNaN, 1, math.NaN()}
+	sort.Slice(in, OfOpts(in, FloatTotalOrder(), NaNLast()))
+	var got []uint64
+	for _, f := range in {
+		got = append(got, math.Float64bits(f))
+	}
+	var want []uint64
+	for _, f := range []float64{negNaN, math.Inf(-1), -2, negZero, 0, 1, math.Inf(1), sNaN, math.NaN(), nan1} {
+		want = append(want, math.Float64bits(f))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %x\nwant %x", got, want)
+	}
+
+	f32 := []float32{0, float32(negZero), -1}
+	sort.Slice(f32, OfOpts(f32, FloatTotalOrder()))
+	if f32[0] != -1 || !math.Signbit(float64(f32[1])) || math.Signbit(float64(f32[2])) {
+		t.Errorf("float32: got %v", f32)
+	}
+
+	c64 := []complex64{complex(0, 1), complex(float32(negZero), 2), complex(0, -1)}
+	sort.Slice(c64, OfOpts(c64, FloatTotalOrder()))
+	if want := []complex64{complex(float32(negZero), 2), complex(0, -1), complex(0, 1)}; !reflect.DeepEqual(c64, want) {
+		t.Errorf("complex64: got %v; want %v", c64, want)
+	}
+
+	if err := ValidateOpts(reflect.TypeOf(0.0), FloatTotalOrder(), NaNLast()); err == nil {
+		t.Error("ValidateOpts: NaNLast with FloatTotalOrder got nil error")
+	}
+}
+
+func TestComplexByMagnitude(t *testing.T) {
+	nan := math.NaN()
+	in := []complex128{3 + 4i, -1, 1i, complex(nan, 0), 5, -5i, 0, 1}
+	sort.Slice(in, OfOpts(in, ComplexByMagnitude()))
+	// Equal magnitudes order by phase: -Pi/2, 0, Pi/2, Pi.
+	want := []string{"(NaN+0i)", "(0+0i)", "(1+0i)", "(0+1i)", "(-1+0i)", "(0-5i)", "(5+0i)", "(3+4i)"}
+	var got []string
+	for _, c := range in {
+		got = append(got, fmt.Sprint(c))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+
+	c64 := []complex64{complex(float32(nan), 0), 2i, 1}
+	sort.Slice(c64, OfOpts(c64, ComplexByMagnitude(), NaNLast()))
+	if c64[0] != 1 || c64[1] != 2i || real(c64[2]) == real(c64[2]) {
+		t.Errorf("complex64 with NaNLast: got %v", c64)
+	}
+
+	// An infinite part makes an infinite magnitude, NaN or not.
+	inf := math.Inf(1)
+	in = []complex128{complex(inf, 0), complex(inf, nan), complex(nan, 1), 1}
+	sort.Slice(in, OfOpts(in, ComplexByMagnitude()))
+	want = []string{"(NaN+1i)", "(1+0i)", "(+Inf+NaNi)", "(+Inf+0i)"}
+	got = got[:0]
+	for _, c := range in {
+		got = append(got, fmt.Sprint(c))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("with infinities: got %v\nwant %v", got, want)
+	}
+}
+
+func TestFloatEpsilonMultiKey(t *testing.T) {
+	// Noisy readings of the same sensors order by sensor ID, not
+	// by noise, whichever direction the sort runs.
+	type reading struct {
+		Temp   float64
+		Sensor int
+	}
+	in := []reading{{20.004, 2}, {19.998, 1}, {25.001, 1}, {20.001, 3}, {24.996, 2}}
+	sort.Slice(in, OfOpts(in, FloatEpsilon(0.1)))
+	want := []reading{{19.998, 1}, {20.004, 2}, {20.001, 3}, {25.001, 1}, {24.996, 2}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v\nwant %v", in, want)
+	}
+	sort.Slice(in, OfOpts(in, FloatEpsilon(0.1), Desc()))
+	want = []reading{{24.996, 2}, {25.001, 1}, {20.001, 3}, {20.004, 2}, {19.998, 1}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("Desc: got %v\nwant %v", in, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Pair is a generic struct. Instantiations have ordinary fields with
+// concrete types and offsets, so Of walks them like any other struct.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+func TestGenericStruct(t *testing.T) {
+	ints := []Pair[int, string]{{2, "a"}, {1, "b"}, {1, "a"}, {-3, "z"}}
+	sort.Slice(ints, Of(ints))
+	wantInts := []Pair[int, string]{{-3, "z"}, {1, "a"}, {1, "b"}, {2, "a"}}
+	if !reflect.DeepEqual(ints, wantInts) {
+		t.Errorf("got %v; want %v", ints, wantInts)
+	}
+
+	floats := []Pair[string, float64]{{"b", 1}, {"a", 2.5}, {"a", -1}, {"", 0}}
+	sort.Slice(floats, Of(floats))
+	wantFloats := []Pair[string, float64]{{"", 0}, {"a", -1}, {"a", 2.5}, {"b", 1}}
+	if !reflect.DeepEqual(floats, wantFloats) {
+		t.Errorf("got %v; want %v", floats, wantFloats)
+	}
+}
+
+func TestGenericNested(t *testing.T) {
+	// The inner instantiation is laid out at a non-zero offset.
+	in := []Pair[string, Pair[int8, [2]uint16]]{
+		{"a", Pair[int8, [2]uint16]{1, [2]uint16{2, 1}}},
+		{"a", Pair[int8, [2]uint16]{1, [2]uint16{1, 9}}},
+		{"a", Pair[int8, [2]uint16]{0, [2]uint16{5, 5}}},
+	}
+	sort.Slice(in, Of(in))
+	var got [][2]uint16
+	for _, p := range in {
+		got = append(got, p.Second.Second)
+	}
+	if want := [][2]uint16{{5, 5}, {1, 9}, {2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestGenericInInterface(t *testing.T) {
+	// Dynamic types order by their String form, which for
+	// instantiations includes the type arguments.
+	in := []interface{}{
+		Pair[string, int]{"x", 1},
+		Pair[int, int]{2, 2},
+		Pair[int, int]{1, 2},
+	}
+	sort.Slice(in, Of(in))
+	want := []interface{}{
+		Pair[int, int]{1, 2},
+		Pair[int, int]{2, 2},
+		Pair[string, int]{"x", 1},
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
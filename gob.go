@@ -0,0 +1,92 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"sort"
+)
+
+// OfGob returns a sort.Interface for slice that orders its elements by
+// their encoding/gob encodings, compared as bytes. It's a last-resort
+// ordering for tooling that needs some deterministic order for values
+// of any gob-encodable type, including those Of can't order.
+//
+// The order is consistent but not meaningful: it follows gob's wire
+// format, not the values' natural order. Nor is gob's output
+// canonical across programs: for interface values, it depends on the
+// names given to types with gob.Register, and map entries are encoded
+// in random order, so elements holding maps with more than one entry
+// don't order reliably.
+//
+// Each element is encoded once, up front, and the encodings are moved
+// along with the elements as they're swapped, so the slice must not be
+// modified other than through the returned value's Swap method, as
+// with OfStringableCached. Elements that fail to encode are equal to
+// each other and order first; use OfGobErr to detect them instead.
+//
+// The slice argument must be a slice.
+func OfGob(slice interface{}) sort.Interface {
+	s, _ := gobSorter(slice)
+	return s
+}
+
+// OfGobErr is like OfGob, but returns the first error encoding an
+// element of slice, if any, instead of a sort.Interface.
+func OfGobErr(slice interface{}) (sort.Interface, error) {
+	s, err := gobSorter(slice)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// gobSorter returns the sort.Interface of OfGob for slice, and the
+// first error encoding one of its elements.
+func gobSorter(slice interface{}) (*keySorter, error) {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	n := rv.Len()
+	encs := make([][]byte, n)
+	failed := make([]bool, n)
+	var firstErr error
+	for i := range encs {
+		var err error
+		encs[i], err = gobBytes(rv.Index(i))
+		if err != nil {
+			failed[i] = true
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	swap := reflect.Swapper(slice)
+	return &keySorter{
+		n: n,
+		less: func(i, j int) bool {
+			if failed[i] || failed[j] {
+				return failed[i] && !failed[j]
+			}
+			return bytes.Compare(encs[i], encs[j]) < 0
+		},
+		swap: func(i, j int) {
+			swap(i, j)
+			encs[i], encs[j] = encs[j], encs[i]
+			failed[i], failed[j] = failed[j], failed[i]
+		},
+	}, firstErr
+}
+
+// gobBytes returns the gob encoding of v by a new Encoder, so that it
+// includes v's type information and stands alone.
+func gobBytes(v reflect.Value) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).EncodeValue(v)
+	return buf.Bytes(), err
+}
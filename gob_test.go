@@ -0,0 +1,89 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type gobRecord struct {
+	Name string
+	Tags []string
+	Fn   func() `gob:"-"`
+}
+
+func TestOfGob(t *testing.T) {
+	in := []gobRecord{
+		{"b", []string{"x"}, nil},
+		{"a", nil, nil},
+		{"b", nil, nil},
+		{"a", []string{"y"}, nil},
+		{"a", nil, nil},
+	}
+	for i := 0; i < 2; i++ {
+		s := append([]gobRecord(nil), in...)
+		if i == 1 {
+			s[0], s[4] = s[4], s[0]
+		}
+		sort.Sort(OfGob(s))
+		if !sort.IsSorted(OfGob(s)) {
+			t.Fatalf("not sorted: %v", s)
+		}
+		if i == 0 {
+			in = s
+		} else if !reflect.DeepEqual(s, in) {
+			t.Errorf("order depends on input order:\n%v\n%v", s, in)
+		}
+	}
+}
+
+func TestOfGobErr(t *testing.T) {
+	if _, err := OfGobErr([]int{3, 1}); err != nil {
+		t.Errorf("ints: %v", err)
+	}
+	bad := []interface{}{1, func() {}}
+	if _, err := OfGobErr(bad); err == nil {
+		t.Error("want error for func value")
+	}
+	sort.Sort(OfGob(bad))
+	if _, ok := bad[0].(func()); !ok {
+		t.Error("element failing to encode should order first")
+	}
+}
+
+func TestOfGobEncodesOnce(t *testing.T) {
+	in := make([]gobCounted, 100)
+	for i := range in {
+		in[i] = gobCounted{N: len(in) - i}
+	}
+	gobEncodes = 0
+	sort.Sort(OfGob(in))
+	if gobEncodes != len(in) {
+		t.Errorf("encoded %d times; want %d", gobEncodes, len(in))
+	}
+	for i := 1; i < len(in); i++ {
+		if in[i-1].N >= in[i].N {
+			t.Fatalf("not sorted at %d: %v", i, in)
+		}
+	}
+}
+
+var gobEncodes int
+
+// gobCounted counts its encodings in gobEncodes, and encodes as a
+// big-endian uint32, so its encodings order as its values do.
+type gobCounted struct{ N int }
+
+func (c gobCounted) GobEncode() ([]byte, error) {
+	gobEncodes++
+	return []byte{byte(c.N >> 24), byte(c.N >> 16), byte(c.N >> 8), byte(c.N)}, nil
+}
+
+func (c *gobCounted) GobDecode(b []byte) error {
+	c.N = int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// HashOrder returns an Option that orders slice elements of struct
+// type by a hash of their field values first, and only elements with
+// equal hashes field by field. Two elements are still equal exactly
+// when all their fields are, but which of two distinct elements comes
+// first is arbitrary: it's deterministic, but has nothing to do with
+// their values or the order of the fields. The hash of each field
+// includes its name, and the fields' hashes are summed.
+//
+// This is meant for FirstDuplicate, GroupBy, SortUnique and similar
+// uses where only equality matters, and for structs whose leading
+// fields often tie, where it avoids comparing them one by one.
+//
+// Fields whose equality isn't a matter of their bits, such as those
+// with a custom ordering or affected by another option, aren't
+// hashed, and are only compared once the hashes are equal.
+func HashOrder() Option {
+	return func(c *config) {
+		c.hashOrder = true
+		c.applied("HashOrder")
+	}
+}
+
+// FNV-1a parameters.
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// fnvString returns h updated with the bytes of s.
+func fnvString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h = (h ^ uint64(s[i])) * fnvPrime
+	}
+	return h
+}
+
+// fnvWord returns h updated with the 8 bytes of v.
+func fnvWord(h, v uint64) uint64 {
+	for i := uint(0); i < 64; i += 8 {
+		h = (h ^ (v >> i & 0xff)) * fnvPrime
+	}
+	return h
+}
+
+// lessHashed wraps the struct ordering next to order by hash first.
+func lessHashed(hash func(p unsafe.Pointer) uint64, off uintptr, next less) less {
+	return func(a, b unsafe.Pointer) bool {
+		if ha, hb := hash(at(a, off)), hash(at(b, off)); ha != hb {
+			return ha < hb
+		}
+		return next(a, b)
+	}
+}
+
+// hashFunc returns a hash of values of type t at path that's equal
+// for values that are equal under c, or nil if values of type t
+// aren't hashed.
+func (c *config) hashFunc(t reflect.Type, path string) func(p unsafe.Pointer) uint64 {
+	if _, ok := c.fields[path]; ok || !hasDefaultOrder(t) {
+		return nil
+	}
+	if _, ok := c.types[t]; ok {
+		return nil
+	}
+	switch k := t.Kind(); {
+	case k == reflect.Bool:
+		return func(p unsafe.Pointer) uint64 {
+			if *(*bool)(p) {
+				return fnvWord(fnvOffset, 1)
+			}
+			return fnvWord(fnvOffset, 0)
+		}
+	case isInt(k):
+		return func(p unsafe.Pointer) uint64 { return fnvWord(fnvOffset, uint64(readInt(k, p))) }
+	case isUint(k):
+		return func(p unsafe.Pointer) uint64 { return fnvWord(fnvOffset, readUint(k, p)) }
+	case k == reflect.Float32 || k == reflect.Float64:
+		if c.floatEps > 0 || c.nanPayloads {
+			return nil
+		}
+		return func(p unsafe.Pointer) uint64 {
+			f := readFloat(k, p)
+			switch {
+			case f == 0:
+				f = 0 // not -0
+			case f != f:
+				f = math.NaN() // all NaNs are equal
+			}
+			return fnvWord(fnvOffset, math.Float64bits(f))
+		}
+	case k == reflect.String:
+		if c.fold || c.asciiFold || c.ignore != nil {
+			return nil
+		}
+		return func(p unsafe.Pointer) uint64 { return fnvString(fnvOffset, *(*string)(p)) }
+	case k == reflect.Array:
+		eh, size := c.hashFunc(t.Elem(), path), t.Elem().Size()
+		if eh == nil {
+			return nil
+		}
+		n := t.Len()
+		return func(p unsafe.Pointer) uint64 {
+			h := uint64(fnvOffset)
+			for i := 0; i < n; i++ {
+				h = fnvWord(h, eh(elem(p, size, i)))
+			}
+			return h
+		}
+	case k == reflect.Struct:
+		type field struct {
+			seed uint64 // hash of the name
+			off  uintptr
+			hash func(p unsafe.Pointer) uint64
+		}
+		var fields []field
+		for _, sf := range c.sortFields(t) {
+			name := joinPath(path, sf.Name)
+			if fh := c.hashFunc(sf.Type, name); fh != nil {
+				fields = append(fields, field{fnvString(fnvOffset, name), sf.Offset, fh})
+			}
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+		return func(p unsafe.Pointer) uint64 {
+			var sum uint64
+			for _, f := range fields {
+				sum += fnvWord(f.seed, f.hash(at(p, f.off)))
+			}
+			return sum
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type hashedRecord struct {
+	Kind  string
+	N     int8
+	F     float64
+	Inner struct {
+		B bool
+		A [2]uint16
+	}
+	R revInt // custom ordering, not hashed
+}
+
+func randomHashedRecords(n int) []hashedRecord {
+	r := rand.New(rand.NewSource(1))
+	floats := []float64{0, math.Copysign(0, -1), 1.5, math.NaN(), math.Float64frombits(0x7ff8000000000001)}
+	s := make([]hashedRecord, n)
+	for i := range s {
+		e := &s[i]
+		e.Kind = []string{"a", "b"}[r.Intn(2)]
+		e.N = int8(r.Intn(2))
+		e.F = floats[r.Intn(len(floats))]
+		e.Inner.B = r.Intn(2) == 1
+		e.Inner.A[r.Intn(2)] = uint16(r.Intn(2))
+		e.R.V = r.Intn(2)
+	}
+	return s
+}
+
+func TestHashOrder(t *testing.T) {
+	s := randomHashedRecords(500)
+	plain, hashed := OfOpts(s), OfOpts(s, HashOrder())
+	for i := range s {
+		for j := range s {
+			pe := !plain(i, j) && !plain(j, i)
+			he := !hashed(i, j) && !hashed(j, i)
+			if pe != he {
+				t.Fatalf("elements %d and %d: equal by default = %v, with HashOrder = %v", i, j, pe, he)
+			}
+			if hashed(i, j) && hashed(j, i) {
+				t.Fatalf("elements %d and %d order before each other", i, j)
+			}
+		}
+	}
+
+	sort.Slice(s, OfOpts(s, HashOrder()))
+	hashed = OfOpts(s, HashOrder())
+	for i := 1; i < len(s); i++ {
+		if hashed(i, i-1) {
+			t.Fatalf("not sorted at %d", i)
+		}
+	}
+	if got, want := len(GroupBy(s)), groupsWith(s, HashOrder()); got != want {
+		t.Errorf("%d groups by default, %d with HashOrder", got, want)
+	}
+}
+
+// groupsWith returns the number of groups of equal elements of s,
+// which must be sorted with opts.
+func groupsWith(s []hashedRecord, opts ...Option) int {
+	less := OfOpts(s, opts...)
+	n := 1
+	for i := 1; i < len(s); i++ {
+		if less(i-1, i) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHashOrderSkipsOptionFields(t *testing.T) {
+	s := []measurement{{1.01, "x"}, {1.04, "x"}}
+	less := OfOpts(s, HashOrder(), FloatEpsilon(0.5))
+	if less(0, 1) || less(1, 0) {
+		t.Error("values equal under FloatEpsilon should stay equal with HashOrder")
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// ifaceWords is the memory layout of an interface value: a pointer to
+// its dynamic type (or, for non-empty interfaces, to an itab, which is
+// unique per dynamic type), and a data word.
+type ifaceWords struct {
+	typ  unsafe.Pointer
+	data unsafe.Pointer
+}
+
+// NumericInterfaceOrder returns an Option that orders interface values
+// holding numbers by their numeric values, whatever their dynamic
+// types, so that int(5) orders after float64(3) rather than before it,
+// as "int" < "float64" would have it. This suits slices decoded from
+// JSON or YAML, which mix integers and floats.
+//
+// Numbers are values of integer and float kinds with no custom
+// ordering. They're compared exactly, without rounding, and order
+// before all other non-nil values, which keep their usual order by
+// dynamic type and then value. NaNs order before other numbers.
+// Numbers that are equal but of different types, such as int(3) and
+// float64(3), are then ordered by their types' names.
+//
+// The json.Number values produced by a json.Decoder with UseNumber
+// are numbers too, compared by the decimal values they spell. Those
+// that aren't valid numbers order like NaNs.
+func NumericInterfaceOrder() Option {
+	return func(c *config) {
+		c.numericIface = true
+		c.applied("NumericInterfaceOrder")
+	}
+}
+
+// lessIface returns a less builder for values of the interface type
+// t. Nil interfaces order first. Non-nil interfaces order by their
+// dynamic types' names first, then by their dynamic values.
+//
+// The slice's interface headers are read directly, but the dynamic
+// values they box aren't addressable, so comparing two values of the
+// same dynamic type copies them to a new pair of addressable values.
+// Compared to other kinds, this costs an allocation and a trip
+// through reflect per comparison.
+//
+// The ordering for each dynamic type is built the first time the
+// type is seen, since it can't be known until then. Interfaces within
+// those orderings share theirs by interface and dynamic type, so a
+// value that refers back to itself through an interface, as with
+// Deref, doesn't build orderings without end; and they share the
+// derefGuard of the pointers they're reached through, which keeps
+// the comparison of such a value from following it forever.
+func (c *config) lessIface(t reflect.Type, path string) func(off uintptr, optEq less) less {
+	// Orderings built at run time mustn't affect ValidateOpts,
+	// which can't see them anyway.
+	dc := *c
+	dc.names, dc.used = nil, nil
+	var dyn sync.Map // dynamic reflect.Type => *boxedLess
+	cache, nested := &dyn, c.nestedDyn != nil
+	if nested {
+		cache = c.nestedDyn
+	} else {
+		dc.nestedDyn = new(sync.Map)
+	}
+	if g := c.derefGuard; g != nil && !g.recursive {
+		// The dynamic values may lead back to the pointer
+		// being dereferenced.
+		g.recursive = true
+	}
+
+	numeric := c.numericIface
+	var numericTypes sync.Map // dynamic reflect.Type => bool
+	isNumber := func(t reflect.Type) bool {
+		if v, ok := numericTypes.Load(t); ok {
+			return v.(bool)
+		}
+		k := t.Kind()
+		ok := (isInt(k) || isUint(k) || k == reflect.Float32 || k == reflect.Float64) && hasDefaultOrder(t) ||
+			isJSONNumber(t)
+		numericTypes.Store(t, ok)
+		return ok
+	}
+	if numeric {
+		c.use("NumericInterfaceOrder")
+	}
+
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			pa, pb := at(a, off), at(b, off)
+			wa, wb := (*ifaceWords)(pa), (*ifaceWords)(pb)
+			if wa.typ == nil || wb.typ == nil {
+				if wa.typ == wb.typ {
+					if optEq != nil {
+						return optEq(a, b)
+					}
+					return false
+				}
+				return wa.typ == nil
+			}
+			va, vb := reflect.NewAt(t, pa).Elem().Elem(), reflect.NewAt(t, pb).Elem().Elem()
+			dt := va.Type()
+			if numeric {
+				na, nb := isNumber(dt), isNumber(vb.Type())
+				if na != nb {
+					return na
+				}
+				if na {
+					if c := cmpNumbers(va, vb); c != 0 {
+						return c < 0
+					}
+				}
+			}
+			if wa.typ != wb.typ {
+				if ta, tb := dt.String(), vb.Type().String(); ta != tb {
+					return ta < tb
+				}
+				return uintptr(wa.typ) < uintptr(wb.typ)
+			}
+			var key interface{} = dt
+			if nested {
+				key = nestedKey{t, dt, dc.derefGuard}
+			}
+			bl, ok := cache.Load(key)
+			if !ok {
+				// Builds may run concurrently, so each
+				// gets its own config.
+				bc := dc
+				bc.building = nil
+				bl, _ = cache.LoadOrStore(key, &boxedLess{
+					pair: reflect.ArrayOf(2, dt),
+					less: bc.forAddr(0, dt, path, nil),
+				})
+			}
+			switch bl.(*boxedLess).cmp(va, vb, optEq == nil) {
+			case -1:
+				return true
+			case 1:
+				return false
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// nestedKey identifies the ordering of the dynamic type dyn of the
+// interface type iface, built under guard, in config.nestedDyn.
+// Orderings built under one derefGuard mustn't be used under another,
+// whose lock doesn't cover it.
+type nestedKey struct {
+	iface, dyn reflect.Type
+	guard      *derefGuard
+}
+
+// boxedLess compares values of one type that aren't addressable.
+type boxedLess struct {
+	pair reflect.Type // [2]T
+	less less         // for T at offset 0
+}
+
+// cmp copies va and vb to addressable memory and compares them,
+// returning -1, 0 or +1. As for derefGuard.cmp, if ltOnly is set, it
+// returns +1 instead of 0, sparing a comparison.
+func (bl *boxedLess) cmp(va, vb reflect.Value, ltOnly bool) int {
+	pair := reflect.New(bl.pair).Elem()
+	pair.Index(0).Set(va)
+	pair.Index(1).Set(vb)
+	pa := unsafe.Pointer(pair.Index(0).UnsafeAddr())
+	pb := unsafe.Pointer(pair.Index(1).UnsafeAddr())
+	switch {
+	case bl.less(pa, pb):
+		return -1
+	case ltOnly, bl.less(pb, pa):
+		return 1
+	}
+	return 0
+}
+
+// cmpNumbers compares the integer or float values a and b exactly, as
+// described by NumericInterfaceOrder, returning -1, 0 or +1.
+func cmpNumbers(a, b reflect.Value) int {
+	fa, nanA := bigNumber(a)
+	fb, nanB := bigNumber(b)
+	switch {
+	case nanA && nanB:
+		return 0
+	case nanA:
+		return -1
+	case nanB:
+		return 1
+	}
+	return fa.Cmp(fb)
+}
+
+// bigNumber returns the integer, float or json.Number value v as a
+// big.Float, or reports that it's a NaN.
+func bigNumber(v reflect.Value) (f *big.Float, nan bool) {
+	switch k := v.Kind(); {
+	case isInt(k):
+		return new(big.Float).SetInt64(v.Int()), false
+	case isUint(k):
+		return new(big.Float).SetUint64(v.Uint()), false
+	case k == reflect.String:
+		// Enough precision to hold a decimal integer of the
+		// string's length exactly.
+		s := v.String()
+		f, ok := new(big.Float).SetPrec(uint(4*len(s) + 64)).SetString(s)
+		return f, !ok
+	}
+	x := v.Float()
+	if math.IsNaN(x) {
+		return nil, true
+	}
+	return new(big.Float).SetFloat64(x), false
+}
+
+// isJSONNumber reports whether t is encoding/json's Number type. It's
+// recognized by name, without importing encoding/json.
+func isJSONNumber(t reflect.Type) bool {
+	return t.Kind() == reflect.String && t.PkgPath() == "encoding/json" && t.Name() == "Number"
+}
@@ -0,0 +1,182 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type boxedPoint struct {
+	X, Y int
+}
+
+func TestInterfaceBoxed(t *testing.T) {
+	in := []interface{}{
+		[3]int{3, 2, 1},
+		boxedPoint{2, 1},
+		[3]int{1, 2, 3},
+		nil,
+		boxedPoint{1, 5},
+		[3]int{1, 2, 0},
+		boxedPoint{1, 2},
+		nil,
+	}
+	want := []interface{}{
+		nil,
+		nil,
+		[3]int{1, 2, 0},
+		[3]int{1, 2, 3},
+		[3]int{3, 2, 1},
+		boxedPoint{1, 2},
+		boxedPoint{1, 5},
+		boxedPoint{2, 1},
+	}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("wrong:\n got: %v\nwant: %v", in, want)
+	}
+}
+
+func TestInterfaceMixed(t *testing.T) {
+	in := []interface{}{3, "a", 3, 1.0, nil}
+	sort.Slice(in, Of(in))
+	// By dynamic type name: "float64" < "int" < "string".
+	want := []interface{}{nil, 1.0, 3, 3, "a"}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("wrong:\n got: %v\nwant: %v", in, want)
+	}
+}
+
+type stringerInt int
+
+func (i stringerInt) String() string { return fmt.Sprint(int(i)) }
+
+type stringerName struct{ S string }
+
+func (n *stringerName) String() string { return n.S }
+
+func TestInterfaceStringers(t *testing.T) {
+	in := []fmt.Stringer{stringerInt(2), &stringerName{"x"}, nil, stringerInt(-1)}
+	sort.Slice(in, Of(in))
+	// "*lesser.stringerName" < "lesser.stringerInt".
+	if in[0] != nil || in[1].String() != "x" || in[2] != stringerInt(-1) || in[3] != stringerInt(2) {
+		t.Errorf("got %v", in)
+	}
+}
+
+func TestInterfaceField(t *testing.T) {
+	type cell struct {
+		V interface{}
+		N int
+	}
+	in := []cell{{"x", 2}, {nil, 5}, {7, 1}, {"x", 1}, {nil, 0}, {7, 0}}
+	sort.Slice(in, Of(in))
+	want := []cell{{nil, 0}, {nil, 5}, {7, 0}, {7, 1}, {"x", 1}, {"x", 2}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("wrong:\n got: %v\nwant: %v", in, want)
+	}
+}
+
+func TestInterfaceZeroSize(t *testing.T) {
+	type none struct{}
+	in := []interface{}{struct{}{}, 1, none{}, struct{}{}, [0]int{}, none{}}
+	sort.Slice(in, Of(in))
+	// "[0]int" < "int" < "lesser.none" < "struct {}".
+	want := []interface{}{[0]int{}, 1, none{}, none{}, struct{}{}, struct{}{}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("wrong:\n got: %v\nwant: %v", in, want)
+	}
+	cell := []struct {
+		V interface{}
+		N int
+	}{{struct{}{}, 2}, {struct{}{}, 1}}
+	if less := Of(cell); !less(1, 0) || less(0, 1) {
+		t.Error("equal zero-size values should fall through to the next field")
+	}
+}
+
+func TestInterfaceBoxedPointerShaped(t *testing.T) {
+	// Single-pointer structs are stored in interfaces directly
+	// rather than boxed; both must work.
+	type wrap struct{ P *int }
+	x := new(int)
+	in := []interface{}{wrap{x}, wrap{nil}, "b", "a"}
+	sort.Slice(in, Of(in))
+	want := []interface{}{wrap{nil}, wrap{x}, "a", "b"} // "lesser.wrap" < "string"
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("wrong:\n got: %v\nwant: %v", in, want)
+	}
+}
+
+func TestNumericInterfaceOrder(t *testing.T) {
+	type celsius float64
+	in := []interface{}{
+		"x",
+		int(5),
+		float64(3.5),
+		nil,
+		uint64(math.MaxUint64),
+		float64(math.MaxUint64), // rounds up to 1<<64
+		int8(-2),
+		math.NaN(),
+		float32(5),
+		celsius(4),
+		int64(math.MinInt64),
+	}
+	sort.Slice(in, OfOpts(in, NumericInterfaceOrder()))
+	want := []interface{}{
+		nil,
+		math.NaN(),
+		int64(math.MinInt64),
+		int8(-2),
+		float64(3.5),
+		celsius(4),
+		float32(5), // "float32" < "int"
+		int(5),
+		uint64(math.MaxUint64),
+		float64(math.MaxUint64),
+		"x",
+	}
+	if got, want := fmt.Sprint(in), fmt.Sprint(want); got != want {
+		t.Errorf("wrong:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestNumericInterfaceOrderJSON(t *testing.T) {
+	const doc = `[10, 2.5, "b", 123456789012345678901234567890, 9.99e2, null, -1, "a", 2, 1e400]`
+	var decoded []interface{}
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded = append(decoded, float64(10), int(2), json.Number("bogus"))
+	sort.Slice(decoded, OfOpts(decoded, NumericInterfaceOrder()))
+	got := fmt.Sprintf("%#v", decoded)
+	want := fmt.Sprintf("%#v", []interface{}{
+		nil,
+		json.Number("bogus"),
+		json.Number("-1"),
+		int(2), // "int" < "json.Number"
+		json.Number("2"),
+		json.Number("2.5"),
+		float64(10), // "float64" < "json.Number"
+		json.Number("10"),
+		json.Number("9.99e2"),
+		json.Number("123456789012345678901234567890"),
+		json.Number("1e400"),
+		"a",
+		"b",
+	})
+	if got != want {
+		t.Errorf("wrong:\n got: %v\nwant: %v", got, want)
+	}
+}
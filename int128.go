@@ -0,0 +1,81 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// RegisterUint128 registers t, a struct type representing the
+// unsigned 128-bit integer hi × 2^64 + lo, to order by numeric value.
+// The named fields must be of kind uint64. Any other fields of t
+// don't affect the ordering.
+//
+// For example, given
+//
+//	type Uint128 struct {
+//		Lo, Hi uint64
+//	}
+//
+// RegisterUint128(reflect.TypeOf(Uint128{}), "Hi", "Lo") makes the
+// high word order first, regardless of field order.
+//
+// RegisterUint128 panics if t is not a struct type with the named
+// fields.
+func RegisterUint128(t reflect.Type, hiField, loField string) {
+	register128(t, hiField, loField, false, "RegisterUint128")
+}
+
+// RegisterInt128 is like RegisterUint128, but for signed 128-bit
+// integers in two's complement, whose high word holds the sign. The
+// high field may be of kind int64 or uint64; either way its bits are
+// compared as an int64, so values with the top bit of hi set order
+// before all others.
+func RegisterInt128(t reflect.Type, hiField, loField string) {
+	register128(t, hiField, loField, true, "RegisterInt128")
+}
+
+func register128(t reflect.Type, hiField, loField string, signed bool, fn string) {
+	hi := int128Field(t, hiField, fn, signed)
+	lo := int128Field(t, loField, fn, false)
+	register(t, func(a, b unsafe.Pointer) int {
+		ha, hb := *(*uint64)(at(a, hi.Offset)), *(*uint64)(at(b, hi.Offset))
+		if ha != hb {
+			if signed {
+				return cmpInt64(int64(ha), int64(hb))
+			}
+			return cmpUint64(ha, hb)
+		}
+		return cmpUint64(*(*uint64)(at(a, lo.Offset)), *(*uint64)(at(b, lo.Offset)))
+	})
+}
+
+// int128Field returns the field named name of the struct type t, checking
+// that it's a 64-bit word for the registration function fn.
+func int128Field(t reflect.Type, name, fn string, allowInt bool) reflect.StructField {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("lesser: %s of non-struct type %v", fn, t))
+	}
+	sf, ok := t.FieldByName(name)
+	if !ok || len(sf.Index) != 1 {
+		panic(fmt.Sprintf("lesser: %s: type %v has no field %q", fn, t, name))
+	}
+	if k := sf.Type.Kind(); k != reflect.Uint64 && !(allowInt && k == reflect.Int64) {
+		panic(fmt.Sprintf("lesser: %s: field %v.%s is not a 64-bit word", fn, t, name))
+	}
+	return sf
+}
+
+func cmpUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
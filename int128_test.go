@@ -0,0 +1,88 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testUint128 has its low word first, so structural order is wrong.
+type testUint128 struct {
+	Lo, Hi uint64
+}
+
+type testInt128 struct {
+	Hi int64
+	Lo uint64
+}
+
+func init() {
+	RegisterUint128(reflect.TypeOf(testUint128{}), "Hi", "Lo")
+	RegisterInt128(reflect.TypeOf(testInt128{}), "Hi", "Lo")
+}
+
+func TestRegisterUint128(t *testing.T) {
+	in := []testUint128{
+		{0, 1},
+		{math.MaxUint64, 0},
+		{0, 1 << 63},
+		{5, 0},
+		{1, 1},
+	}
+	sort.Slice(in, Of(in))
+	want := []testUint128{
+		{5, 0},
+		{math.MaxUint64, 0},
+		{0, 1},
+		{1, 1},
+		{0, 1 << 63},
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v\nwant %v", in, want)
+	}
+}
+
+func TestRegisterInt128(t *testing.T) {
+	in := []testInt128{
+		{0, 0},                   // 0
+		{-1, math.MaxUint64},     // -1
+		{0, math.MaxUint64},      // 2^64-1
+		{-1, 0},                  // -2^64
+		{math.MinInt64, 0},       // min
+		{math.MaxInt64, 0},       // near max
+		{1, 0},                   // 2^64
+		{-1, math.MaxUint64 - 1}, // -2
+	}
+	sort.Slice(in, Of(in))
+	want := []testInt128{
+		{math.MinInt64, 0},
+		{-1, 0},
+		{-1, math.MaxUint64 - 1},
+		{-1, math.MaxUint64},
+		{0, 0},
+		{0, math.MaxUint64},
+		{1, 0},
+		{math.MaxInt64, 0},
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v\nwant %v", in, want)
+	}
+}
+
+func TestRegisterInt128Panics(t *testing.T) {
+	type bad struct {
+		Hi int32
+		Lo uint64
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	RegisterUint128(reflect.TypeOf(bad{}), "Hi", "Lo")
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"unsafe"
+)
+
+func init() {
+	// A net.IP may hold an IPv4 address in 4- or 16-byte form, so
+	// compare addresses rather than bytes.
+	register(reflect.TypeOf(net.IP(nil)), func(a, b unsafe.Pointer) int {
+		return cmpIP(*(*net.IP)(a), *(*net.IP)(b))
+	})
+	registerNetipAddrs()
+}
+
+// cmpIP compares IP addresses like netip.Addr.Compare: invalid
+// addresses (including nil) first, then IPv4 addresses in either
+// form, then IPv6 addresses, each by value.
+func cmpIP(a, b net.IP) int {
+	fa, ia := ipAddr(a)
+	fb, ib := ipAddr(b)
+	if fa != fb {
+		return fa - fb
+	}
+	return bytes.Compare(ia, ib)
+}
+
+// ipAddr returns the family of ip, as for ipFamily, and its address
+// in the shortest form.
+func ipAddr(ip net.IP) (family int, addr net.IP) {
+	if v4 := ip.To4(); v4 != nil {
+		return 4, v4
+	}
+	if len(ip) == net.IPv6len {
+		return 6, ip
+	}
+	return 0, nil
+}
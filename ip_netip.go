@@ -0,0 +1,42 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package lesser
+
+import (
+	"net/netip"
+	"reflect"
+	"unsafe"
+)
+
+// registerNetipAddrs registers the net/netip types, whose fields
+// include an interned zone pointer, to order by address:
+//
+//   - netip.Addr by its Compare method: invalid first, then IPv4
+//     before IPv6, then by value and zone
+//   - netip.AddrPort by address, then port
+//   - netip.Prefix by address, then prefix length
+//
+// RegisterNetPrefix replaces the ordering of netip.Prefix.
+func registerNetipAddrs() {
+	register(reflect.TypeOf(netip.Addr{}), func(a, b unsafe.Pointer) int {
+		return (*netip.Addr)(a).Compare(*(*netip.Addr)(b))
+	})
+	register(reflect.TypeOf(netip.AddrPort{}), func(a, b unsafe.Pointer) int {
+		pa, pb := *(*netip.AddrPort)(a), *(*netip.AddrPort)(b)
+		if c := pa.Addr().Compare(pb.Addr()); c != 0 {
+			return c
+		}
+		return int(pa.Port()) - int(pb.Port())
+	})
+	register(reflect.TypeOf(netip.Prefix{}), func(a, b unsafe.Pointer) int {
+		pa, pb := *(*netip.Prefix)(a), *(*netip.Prefix)(b)
+		if c := pa.Addr().Compare(pb.Addr()); c != 0 {
+			return c
+		}
+		return pa.Bits() - pb.Bits()
+	})
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package lesser
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNetipAddrs(t *testing.T) {
+	addrs := []netip.Addr{
+		netip.MustParseAddr("fe80::1%eth1"),
+		netip.MustParseAddr("10.0.0.10"),
+		netip.MustParseAddr("::ffff:10.0.0.1"),
+		{},
+		netip.MustParseAddr("fe80::1%eth0"),
+		netip.MustParseAddr("10.0.0.9"),
+	}
+	want := []netip.Addr{addrs[3], addrs[5], addrs[1], addrs[2], addrs[4], addrs[0]}
+	sort.Slice(addrs, Of(addrs))
+	if !reflect.DeepEqual(addrs, want) {
+		t.Errorf("addrs: got %v\nwant %v", addrs, want)
+	}
+
+	type listener struct {
+		AddrPort netip.AddrPort
+		Name     string
+	}
+	ls := []listener{
+		{netip.MustParseAddrPort("[::1]:80"), "a"},
+		{netip.MustParseAddrPort("127.0.0.1:8080"), "b"},
+		{netip.MustParseAddrPort("127.0.0.1:443"), "c"},
+		{netip.MustParseAddrPort("127.0.0.1:443"), "d"},
+	}
+	sort.Slice(ls, Of(ls))
+	if got, want := fmt.Sprint(ls), "[{127.0.0.1:443 c} {127.0.0.1:443 d} {127.0.0.1:8080 b} {[::1]:80 a}]"; got != want {
+		t.Errorf("listeners: got %v\nwant %v", got, want)
+	}
+
+	// Distinct networks, which order the same with RegisterNetPrefix.
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("192.168.1.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		{},
+		netip.MustParsePrefix("100.64.0.0/10"),
+	}
+	sort.Slice(prefixes, Of(prefixes))
+	if got, want := fmt.Sprint(prefixes), "[invalid Prefix 10.0.0.0/8 100.64.0.0/10 192.168.1.0/24 2001:db8::/32]"; got != want {
+		t.Errorf("prefixes: got %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.18
+
+package lesser
+
+// registerNetipAddrs is a no-op before net/netip existed.
+func registerNetipAddrs() {}
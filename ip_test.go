@@ -0,0 +1,44 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNetIP(t *testing.T) {
+	in := []net.IP{
+		net.ParseIP("2001:db8::1"),
+		net.ParseIP("10.0.0.2"), // 16-byte form
+		net.IPv4(10, 0, 0, 1).To4(),
+		nil,
+		net.ParseIP("::1"),
+		net.ParseIP("192.168.0.1"),
+		{1, 2, 3}, // invalid
+	}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, ip := range in {
+		got = append(got, ip.String())
+	}
+	want := []string{"<nil>", "?010203", "10.0.0.1", "10.0.0.2", "192.168.0.1", "::1", "2001:db8::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	// Both forms of an IPv4 address are equal.
+	type host struct {
+		IP   net.IP
+		Name string
+	}
+	hosts := []host{{net.ParseIP("10.0.0.1"), "b"}, {net.IPv4(10, 0, 0, 1).To4(), "a"}}
+	sort.Slice(hosts, Of(hosts))
+	if hosts[0].Name != "a" {
+		t.Errorf("hosts: got %v", hosts)
+	}
+}
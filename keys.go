@@ -0,0 +1,369 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// ByJoinedFields returns a sort.Interface for slice, a slice of
+// structs, that orders its elements by the named fields joined with
+// sep into a single string.
+//
+// Unlike ordering field by field, which puts every "Mary" before
+// every "Mary Ann", this orders first and last names "Mary Ann" and
+// "Jones" before "Mary" and "Smith", as the full names compare.
+// Fields that aren't strings are formatted with fmt.Sprint. Fields
+// are named as for Modulo.
+//
+// The joined strings are ordered as by OfOpts with opts, so options
+// for strings, such as Fold, Natural or StringOrder, apply to them.
+// They're built once per element, when ByJoinedFields is called, and
+// moved along with the elements as they're swapped. The slice must
+// therefore not be modified other than through the returned value's
+// Swap method.
+//
+// ByJoinedFields panics if slice isn't a slice or a named field
+// doesn't exist.
+func ByJoinedFields(slice interface{}, sep string, fields []string, opts ...Option) sort.Interface {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	et := rv.Type().Elem()
+	sfs := make([]reflect.StructField, len(fields))
+	for i, path := range fields {
+		sfs[i] = mustFieldByPath(et, path)
+	}
+	c := newConfig(opts)
+	strLess := c.lessElem(reflect.TypeOf(""), nil)
+	if c.err != nil {
+		panic(c.err.Error())
+	}
+	n := rv.Len()
+	if n == 0 {
+		return &keySorter{}
+	}
+	addr0, size := unsafe.Pointer(rv.Index(0).UnsafeAddr()), et.Size()
+	keys := make([]string, n)
+	var buf strings.Builder
+	for i := range keys {
+		p := elem(addr0, size, i)
+		buf.Reset()
+		for j, sf := range sfs {
+			if j > 0 {
+				buf.WriteString(sep)
+			}
+			fp := at(p, sf.Offset)
+			if sf.Type.Kind() == reflect.String {
+				buf.WriteString(*(*string)(fp))
+			} else {
+				fmt.Fprint(&buf, reflect.NewAt(sf.Type, fp).Elem().Interface())
+			}
+		}
+		keys[i] = buf.String()
+	}
+	swap := reflect.Swapper(slice)
+	return &keySorter{
+		n: n,
+		less: func(i, j int) bool {
+			return strLess(unsafe.Pointer(&keys[i]), unsafe.Pointer(&keys[j]))
+		},
+		swap: func(i, j int) {
+			swap(i, j)
+			keys[i], keys[j] = keys[j], keys[i]
+		},
+	}
+}
+
+// LastKey is a key for ByTypeSwitch that orders after all others, for
+// values of types the key function doesn't handle.
+const LastKey int64 = math.MaxInt64
+
+// ByTypeSwitch returns a less function suitable for passing to
+// sort.Slice that orders the elements of slice by the keys key
+// returns for them, and elements with equal keys by the ordering of
+// Of. It's typically used with slices of interfaces, where key is a
+// type switch ranking the concrete types the caller knows about:
+//
+//	less := lesser.ByTypeSwitch(nodes, func(v interface{}) int64 {
+//		switch v.(type) {
+//		case *ast.Ident:
+//			return 0
+//		case *ast.BasicLit:
+//			return 1
+//		}
+//		return lesser.LastKey
+//	})
+//
+// The key function is called twice per comparison, so it should be
+// cheap.
+//
+// ByTypeSwitch panics if slice isn't a slice or its elements can't be
+// ordered.
+func ByTypeSwitch(slice interface{}, key func(v interface{}) int64) func(i, j int) bool {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	et := rv.Type().Elem()
+	c := newConfig(nil)
+	next := c.forAddr(0, et, "", nil)
+	if c.err != nil {
+		panic(c.err.Error())
+	}
+	if rv.Len() == 0 {
+		return nil // won't be called
+	}
+	return bind(func(a, b unsafe.Pointer) bool {
+		ka := key(reflect.NewAt(et, a).Elem().Interface())
+		kb := key(reflect.NewAt(et, b).Elem().Interface())
+		if ka != kb {
+			return ka < kb
+		}
+		return next(a, b)
+	}, unsafe.Pointer(rv.Index(0).UnsafeAddr()), et.Size())
+}
+
+// ByDistanceTo returns a sort.Interface for slice that orders its
+// elements by the Levenshtein edit distance, in runes, from the string
+// field at path to reference, closest first. Elements at equal
+// distances order by the field's value. The path is as for Modulo, or
+// empty if slice is a slice of strings.
+//
+// Distances are computed once per element, when ByDistanceTo is
+// called, and moved along with the elements as they're swapped. The
+// slice must therefore not be modified other than through the
+// returned value's Swap method.
+//
+// ByDistanceTo panics if slice isn't a slice or the field isn't a
+// string.
+func ByDistanceTo(slice interface{}, path, reference string) sort.Interface {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	sf := mustFieldByPath(rv.Type().Elem(), path)
+	if sf.Type.Kind() != reflect.String {
+		panic(fmt.Sprintf("lesser: ByDistanceTo field %q of type %v is not a string", path, sf.Type))
+	}
+	n := rv.Len()
+	if n == 0 {
+		return &keySorter{}
+	}
+	addr0, size := unsafe.Pointer(rv.Index(0).UnsafeAddr()), rv.Type().Elem().Size()
+	str := func(i int) string { return *(*string)(at(elem(addr0, size, i), sf.Offset)) }
+
+	ref := []rune(reference)
+	dist := make([]int, n)
+	for i := range dist {
+		dist[i] = levenshtein([]rune(str(i)), ref)
+	}
+	swap := reflect.Swapper(slice)
+	return &keySorter{
+		n: n,
+		less: func(i, j int) bool {
+			if dist[i] != dist[j] {
+				return dist[i] < dist[j]
+			}
+			return str(i) < str(j)
+		},
+		swap: func(i, j int) {
+			swap(i, j)
+			dist[i], dist[j] = dist[j], dist[i]
+		},
+	}
+}
+
+// OfStringableCached returns a sort.Interface for slice that orders
+// its elements by their String methods, calling String once per
+// element rather than on every comparison. The elements, or their
+// addresses, must implement fmt.Stringer. Nil pointer and interface
+// elements order first, without calling String, and elements with
+// equal strings are equal.
+//
+// This makes ordering by String practical for large slices of types
+// that are expensive to print or that Of can't order. The strings are
+// moved along with the elements as they're swapped, so the slice must
+// not be modified other than through the returned value's Swap
+// method.
+//
+// OfStringableCached panics if slice isn't a slice or its elements
+// aren't Stringers.
+func OfStringableCached(slice interface{}) sort.Interface {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	et := rv.Type().Elem()
+	byAddr := !et.Implements(stringerType)
+	if byAddr && !reflect.PtrTo(et).Implements(stringerType) {
+		panic(fmt.Sprintf("lesser: OfStringableCached element type %v is not a fmt.Stringer", et))
+	}
+	n := rv.Len()
+	strs := make([]string, n)
+	isNil := make([]bool, n)
+	for i := range strs {
+		v := rv.Index(i)
+		switch {
+		case byAddr:
+			v = v.Addr()
+		case v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface:
+			if v.IsNil() {
+				isNil[i] = true
+				continue
+			}
+		}
+		strs[i] = v.Interface().(fmt.Stringer).String()
+	}
+	swap := reflect.Swapper(slice)
+	return &keySorter{
+		n: n,
+		less: func(i, j int) bool {
+			if isNil[i] || isNil[j] {
+				return isNil[i] && !isNil[j]
+			}
+			return strs[i] < strs[j]
+		},
+		swap: func(i, j int) {
+			swap(i, j)
+			strs[i], strs[j] = strs[j], strs[i]
+			isNil[i], isNil[j] = isNil[j], isNil[i]
+		},
+	}
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// ByWeightedFields returns a sort.Interface for slice that orders its
+// elements by a score, the sum of each numeric field named in weights
+// multiplied by its weight, highest score first. This suits rankings
+// like ByWeightedFields(results, map[string]float64{"Relevance": 0.7,
+// "Recency": 0.3}). Field paths are as for Modulo.
+//
+// Scores are computed once per element, when ByWeightedFields is
+// called, and moved along with the elements as they're swapped, so
+// the slice must not be modified other than through the returned
+// value's Swap method. Elements with equal scores are equal; use
+// sort.Stable to keep them in input order. NaN scores order last.
+//
+// ByWeightedFields returns an error if a named field doesn't exist or
+// isn't of an integer or float kind. It panics if slice isn't a
+// slice.
+func ByWeightedFields(slice interface{}, weights map[string]float64) (sort.Interface, error) {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		panic("slice argument is not a slice")
+	}
+	et := rv.Type().Elem()
+
+	// Sum in a fixed order, so equal elements get equal scores.
+	paths := make([]string, 0, len(weights))
+	for path := range weights {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	fields := make([]reflect.StructField, len(paths))
+	for i, path := range paths {
+		sf, ok := fieldByPath(et, path)
+		if !ok {
+			return nil, fmt.Errorf("lesser: type %v has no field %q", et, path)
+		}
+		if k := sf.Type.Kind(); !isInt(k) && !isUint(k) && k != reflect.Float32 && k != reflect.Float64 {
+			return nil, fmt.Errorf("lesser: ByWeightedFields field %q of type %v is not numeric", path, sf.Type)
+		}
+		fields[i] = sf
+	}
+
+	n := rv.Len()
+	score := make([]float64, n)
+	if n > 0 {
+		addr0, size := unsafe.Pointer(rv.Index(0).UnsafeAddr()), et.Size()
+		for i := range score {
+			p := elem(addr0, size, i)
+			for j, sf := range fields {
+				score[i] += weights[paths[j]] * readFloat(sf.Type.Kind(), at(p, sf.Offset))
+			}
+		}
+	}
+	swap := reflect.Swapper(slice)
+	return &keySorter{
+		n: n,
+		less: func(i, j int) bool {
+			si, sj := score[i], score[j]
+			if math.IsNaN(si) || math.IsNaN(sj) {
+				return !math.IsNaN(si) && math.IsNaN(sj)
+			}
+			return si > sj
+		},
+		swap: func(i, j int) {
+			swap(i, j)
+			score[i], score[j] = score[j], score[i]
+		},
+	}, nil
+}
+
+// readFloat reads the number of integer or float kind k at p as a
+// float64.
+func readFloat(k reflect.Kind, p unsafe.Pointer) float64 {
+	switch {
+	case k == reflect.Float32:
+		return float64(*(*float32)(p))
+	case k == reflect.Float64:
+		return *(*float64)(p)
+	case isInt(k):
+		return float64(readInt(k, p))
+	}
+	return float64(readUint(k, p))
+}
+
+// keySorter is a sort.Interface for a slice ordered by keys computed
+// up front, which its swap function moves along with the elements.
+type keySorter struct {
+	n    int
+	less func(i, j int) bool
+	swap func(i, j int)
+}
+
+func (s *keySorter) Len() int           { return s.n }
+func (s *keySorter) Less(i, j int) bool { return s.less(i, j) }
+func (s *keySorter) Swap(i, j int)      { s.swap(i, j) }
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := range a {
+		prev := row[0] // row[j-1] of the previous row
+		row[0] = i + 1
+		for j := range b {
+			cur := row[j+1]
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			row[j+1] = min3(row[j]+1, cur+1, prev+cost)
+			prev = cur
+		}
+	}
+	return row[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
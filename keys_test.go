@@ -0,0 +1,225 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type person struct {
+	First, Last string
+	Age         int
+}
+
+func TestByJoinedFields(t *testing.T) {
+	in := []person{
+		{"Mary", "Smith", 40},
+		{"Mary Ann", "Jones", 30},
+		{"Bob", "Jones", 20},
+		{"Mary", "Anderson", 29},
+	}
+	sort.Sort(ByJoinedFields(in, " ", []string{"First", "Last"}))
+	var got []string
+	for _, p := range in {
+		got = append(got, p.First+"|"+p.Last)
+	}
+	// Field-by-field ordering would put "Mary" before "Mary Ann".
+	want := []string{"Bob|Jones", "Mary|Anderson", "Mary Ann|Jones", "Mary|Smith"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestByJoinedFieldsOpts(t *testing.T) {
+	in := []person{
+		{"mary", "smith 10", 1},
+		{"Mary", "Smith 9", 2},
+		{"MARY", "anderson", 3},
+	}
+	ages := func() (ret []int) {
+		for _, p := range in {
+			ret = append(ret, p.Age)
+		}
+		return ret
+	}
+	sort.Sort(ByJoinedFields(in, " ", []string{"First", "Last"}, Fold(), Natural()))
+	if got, want := ages(), []int{3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fold, Natural: got ages %v; want %v", got, want)
+	}
+	sort.Sort(ByJoinedFields(in, " ", []string{"First", "Last"}, Fold()))
+	// Without Natural, "mary smith 10" < "mary smith 9".
+	if got, want := ages(), []int{3, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fold: got ages %v; want %v", got, want)
+	}
+	sort.Sort(ByJoinedFields(in, " ", []string{"First", "Last"}, Fold(), Desc()))
+	// Folded, "mary smith 9" > "mary smith 10" > "mary anderson".
+	if got, want := ages(), []int{2, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fold, Desc: got ages %v; want %v", got, want)
+	}
+}
+
+func TestByJoinedFieldsNonString(t *testing.T) {
+	in := []person{{"a", "x", 10}, {"a", "x", 9}, {"a", "", 100}}
+	sort.Sort(ByJoinedFields(in, "-", []string{"Age", "First"}))
+	// "10-a" < "100-a" < "9-a"
+	want := []int{10, 100, 9}
+	for i, p := range in {
+		if p.Age != want[i] {
+			t.Fatalf("got %v; want ages %v", in, want)
+		}
+	}
+}
+
+func TestByTypeSwitch(t *testing.T) {
+	in := []interface{}{"b", 2.5, 3, struct{}{}, "a", 1, nil}
+	less := ByTypeSwitch(in, func(v interface{}) int64 {
+		switch v.(type) {
+		case int:
+			return 0
+		case string:
+			return 1
+		case float64:
+			return 2
+		}
+		return LastKey
+	})
+	sort.Slice(in, less)
+	want := []interface{}{1, 3, "a", "b", 2.5, nil, struct{}{}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
+
+func TestByDistanceTo(t *testing.T) {
+	in := []person{
+		{"apple", "", 1},
+		{"appel", "", 2},
+		{"xyz", "", 3},
+		{"aple", "", 4},
+		{"apple", "", 0},
+		{"", "", 5},
+	}
+	sort.Sort(ByDistanceTo(in, "First", "apple"))
+	var got []string
+	for _, p := range in {
+		got = append(got, p.First)
+	}
+	want := []string{"apple", "apple", "aple", "appel", "", "xyz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	ss := []string{"kitten", "sitting", "mitten", "kit"}
+	sort.Sort(ByDistanceTo(ss, "", "kitten"))
+	if want := []string{"kitten", "mitten", "kit", "sitting"}; !reflect.DeepEqual(ss, want) {
+		t.Errorf("got %q; want %q", ss, want)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"日本", "日本語", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// costly counts calls to its String method.
+type costly struct {
+	N     int
+	calls *int
+}
+
+func (c costly) String() string {
+	*c.calls++
+	return fmt.Sprintf("n=%03d", c.N)
+}
+
+type ptrStringer struct{ S string }
+
+func (p *ptrStringer) String() string { return p.S }
+
+func TestOfStringableCached(t *testing.T) {
+	calls := 0
+	var in []costly
+	for _, n := range []int{50, 7, 300, 7, 12, 1, 99} {
+		in = append(in, costly{n, &calls})
+	}
+	sort.Sort(OfStringableCached(in))
+	var got []int
+	for _, c := range in {
+		got = append(got, c.N)
+	}
+	if want := []int{1, 7, 7, 12, 50, 99, 300}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if calls != len(in) {
+		t.Errorf("String called %d times; want %d", calls, len(in))
+	}
+
+	ptrs := []*ptrStringer{{"b"}, nil, {"a"}}
+	sort.Sort(OfStringableCached(ptrs))
+	if ptrs[0] != nil || ptrs[1].S != "a" || ptrs[2].S != "b" {
+		t.Errorf("pointers: got %v, %v, %v", ptrs[0], ptrs[1], ptrs[2])
+	}
+
+	vals := []ptrStringer{{"z"}, {"y"}}
+	sort.Sort(OfStringableCached(vals))
+	if want := []ptrStringer{{"y"}, {"z"}}; !reflect.DeepEqual(vals, want) {
+		t.Errorf("values with pointer method: got %v; want %v", vals, want)
+	}
+}
+
+func TestByWeightedFields(t *testing.T) {
+	type result struct {
+		Name      string
+		Relevance float64
+		Stats     struct{ Recency uint8 }
+	}
+	mk := func(name string, rel float64, rec uint8) result {
+		r := result{Name: name, Relevance: rel}
+		r.Stats.Recency = rec
+		return r
+	}
+	in := []result{
+		mk("a", 1, 0),  // 0.7
+		mk("b", 0, 10), // 3
+		mk("c", 2, 5),  // 2.9
+		mk("d", math.NaN(), 100),
+		mk("e", 10, 0), // 7
+	}
+	s, err := ByWeightedFields(in, map[string]float64{"Relevance": 0.7, "Stats.Recency": 0.3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Sort(s)
+	var got []string
+	for _, r := range in {
+		got = append(got, r.Name)
+	}
+	if want := []string{"e", "b", "c", "a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	for _, path := range []string{"Name", "Missing"} {
+		if _, err := ByWeightedFields(in, map[string]float64{path: 1}); err == nil {
+			t.Errorf("field %q: want error", path)
+		}
+	}
+}
@@ -14,37 +14,368 @@ import (
 
 // Of returns a less function suitable to passing to sort.Slice.
 //
-// The slice argument must be a slice.
+// The slice argument must be a slice, or a pointer to an array, such
+// as a *[4]T, whose elements are ordered in place as if by Of(arr[:]).
+// Arrays themselves are passed by copy and so are rejected; pass a
+// pointer to them instead.
 //
 // The ordering rules are more general than with Go's < operator:
 //
 //  - bool compares false before true
 //  - ints, floats, and strings order by <
-//  - NaN compares less than non-NaN floats
-//  - complex compares real, then imag
+//  - NaN compares less than non-NaN floats (but see
+//    NaNLast and FloatTotalOrder), and equal to other NaNs
+//  - complex compares real, then imag (but see
+//    ComplexByMagnitude)
 //  - pointers, chan, func and map compare by
-//    machine address
-//  - structs compare each field in turn
+//    machine address (but see Deref, DeepMaps and Strict)
+//  - structs compare each field in turn, skipping fields
+//    tagged `lesser:"-"` and reversing those tagged
+//    `lesser:"desc"`
 //  - arrays compare each non-blank element in turn
+//  - slices compare each element in turn, and a slice that is
+//    a prefix of another orders first
+//  - types with a method Cmp(T) int, or whose pointer type
+//    has a method Cmp(*T) int, order by it (nil first)
+//  - likewise, types with a method Compare(T) int or
+//    Less(T) bool order by it
+//  - types implementing Ordered, or whose pointer type
+//    does, order by LessThan (nil first)
+//  - interface types with a method Cmp(T) int (or
+//    Compare(T) int) order by it, dispatched on each
+//    element's dynamic type (nil interfaces first, then
+//    those holding nil pointers)
+//  - error values compare nil first, then by message
+//  - other interfaces compare nil first, then by the name
+//    of their dynamic type, then by their dynamic value
+//  - time.Time orders chronologically
+//  - the typed atomics of sync/atomic, such as atomic.Int64,
+//    order by their loaded values
+//  - database/sql's nullable types, such as sql.NullString,
+//    order NULL first (but see NilsLast), then by value
+//  - net.IP, netip.Addr, netip.AddrPort and netip.Prefix
+//    order by address, IPv4 before IPv6 (but see
+//    RegisterNetPrefix)
+//  - types registered with RegisterDecimal, RegisterUint128
+//    or RegisterInt128 compare by numeric value
+//
+// The returned function reads the elements of slice's backing array
+// directly. If slice is later grown with append, or re-sliced, the
+// function continues to read the old array, so it must not be used to
+// sort the new slice. Call Of again, or use OfPtr or a Comparator.
+//
+// Unexported struct fields participate in the ordering just like
+// exported ones; see IncludeUnexported.
 //
 // Performance should be comparable to writing a native sort.Slice
-// function.
+// function. The ordering for each element type is built once and
+// reused by later calls, so calling Of for each sort is cheap.
 func Of(slice interface{}) (less func(i, j int) bool) {
+	return OfOpts(slice)
+}
+
+// OfOpts is like Of, but modifies the ordering rules according to
+// opts. Orderings are reused only by calls with the same element
+// type and equivalent options.
+func OfOpts(slice interface{}, opts ...Option) (less func(i, j int) bool) {
+	return ofValue(reflect.ValueOf(slice), newConfig(opts))
+}
+
+// OfReverse is like Of, but orders elements from greatest to least,
+// so NaNs order last. It's OfOpts with Desc. Equal elements are still
+// equal, so the result remains a strict weak ordering.
+func OfReverse(slice interface{}) (less func(i, j int) bool) {
+	return OfOpts(slice, Desc())
+}
+
+// OfErr is like Of, but returns an error instead of panicking if
+// slice isn't a slice or its elements can't be ordered. The error
+// names the field path and type of the first value that can't be,
+// as in "lesser: field Foo.Bar: unsortable type T".
+//
+// This suits libraries ordering values of types they don't control.
+func OfErr(slice interface{}) (less func(i, j int) bool, err error) {
+	return OfOptsErr(slice)
+}
+
+// OfOptsErr is like OfOpts, but returns an error instead of panicking,
+// as described by OfErr.
+func OfOptsErr(slice interface{}, opts ...Option) (less func(i, j int) bool, err error) {
+	rv, ok := asSlice(reflect.ValueOf(slice))
+	if !ok {
+		if rv.Kind() == reflect.Array {
+			return nil, errArray(rv.Type())
+		}
+		return nil, fmt.Errorf("lesser: argument of type %T is not a slice", slice)
+	}
+	return ofValueErr(rv, newConfig(opts))
+}
+
+// OfValue is like Of, but takes the slice as a reflect.Value, which
+// must be of kind Slice, or a pointer to an array.
+//
+// Elements of a slice are always addressable, so rv itself need not
+// be.
+func OfValue(rv reflect.Value) (less func(i, j int) bool) {
+	return ofValue(rv, newConfig(nil))
+}
+
+// OfSnapshot is like Of, but the returned function checks the indexes
+// it's given against the length of slice at the time OfSnapshot was
+// called, and panics with a descriptive message if either is out of
+// range. Without the check, a less function used with a slice that
+// has since grown reads past the end of the array it was built for.
+//
+// The check costs two comparisons per call, which is small compared to
+// the rest of the work but measurable for slices of simple types.
+func OfSnapshot(slice interface{}) (less func(i, j int) bool) {
 	rv := reflect.ValueOf(slice)
-	t := rv.Type()
-	if t.Kind() != reflect.Slice {
+	base := OfValue(rv)
+	n := rv.Len()
+	return func(i, j int) bool {
+		if uint(i) >= uint(n) || uint(j) >= uint(n) {
+			panic(fmt.Sprintf("lesser: less(%d, %d) out of range for snapshot of slice with length %d", i, j, n))
+		}
+		return base(i, j)
+	}
+}
+
+// OfPtr is like Of, but takes a pointer to a slice, such as a *[]T,
+// and the returned function reads the slice through it on each call.
+// It therefore stays correct as the slice is re-sliced or grown with
+// append, even when that moves it to a new backing array, unlike the
+// functions returned by Of, which keep reading the array they were
+// built for. The cost is an extra load of the slice's address per
+// comparison.
+//
+// Like Of, the returned function doesn't check its indexes against
+// the slice's current length.
+//
+// OfPtr panics if slicePtr isn't a non-nil pointer to a slice, or if
+// the slice's elements can't be ordered.
+func OfPtr(slicePtr interface{}) (less func(i, j int) bool) {
+	pv := reflect.ValueOf(slicePtr)
+	if pv.Kind() != reflect.Ptr || pv.IsNil() || pv.Elem().Kind() != reflect.Slice {
+		panic(fmt.Sprintf("lesser: OfPtr argument of type %T is not a pointer to a slice", slicePtr))
+	}
+	et := pv.Type().Elem().Elem()
+	c := newConfig(nil)
+	elemLess := c.cachedLess(et)
+	if c.err != nil {
+		panic(c.err.Error())
+	}
+	s := (*sliceHeader)(unsafe.Pointer(pv.Pointer()))
+	size := et.Size()
+	return func(i, j int) bool {
+		return elemLess(elem(s.data, size, i), elem(s.data, size, j))
+	}
+}
+
+// Before reports whether slice[i] orders before slice[j] under the
+// ordering of Of. It's a convenience for one-off comparisons; to make
+// many, call Of once instead.
+//
+// Before panics if i or j is out of range.
+func Before(slice interface{}, i, j int) bool {
+	rv := mustSlice(reflect.ValueOf(slice))
+	if n := rv.Len(); uint(i) >= uint(n) || uint(j) >= uint(n) {
+		panic(fmt.Sprintf("lesser: index (%d, %d) out of range for slice with length %d", i, j, n))
+	}
+	return OfValue(rv)(i, j)
+}
+
+// After reports whether slice[i] orders after slice[j] under the
+// ordering of Of. It's the same as Before(slice, j, i).
+func After(slice interface{}, i, j int) bool {
+	return Before(slice, j, i)
+}
+
+// Compare returns a three-way comparison of the elements of slice,
+// using the ordering of Of: cmp(i, j) is -1 if slice[i] orders before
+// slice[j], +1 if it orders after, and 0 if they're equal. This is the
+// form taken by functions like slices.SortFunc and
+// slices.BinarySearchFunc, once adapted to the values' indexes.
+//
+// cmp(i, j) < 0 exactly when Of(slice)(i, j). Struct fields and
+// array elements are compared in turn until one differs, each only
+// once, so cmp costs about as much as a call to Of's less function.
+// Slices, interfaces and types with a Less method are the exception:
+// telling those that are equal from those ordering after takes a
+// second comparison.
+//
+// The slice argument must be a slice or a pointer to an array, as for
+// Of.
+func Compare(slice interface{}) (cmp func(i, j int) int) {
+	rv := mustSlice(reflect.ValueOf(slice))
+	c := newConfig(nil)
+	et := rv.Type().Elem()
+	elemCmp := c.cmpFor(0, et, "")
+	if c.err != nil {
+		panic(c.err.Error())
+	}
+	if rv.Len() == 0 {
+		return nil // won't be called
+	}
+	addr0, size := unsafe.Pointer(rv.Index(0).UnsafeAddr()), et.Size()
+	return func(i, j int) int {
+		return elemCmp(elem(addr0, size, i), elem(addr0, size, j))
+	}
+}
+
+func ofValue(rv reflect.Value, c *config) func(i, j int) bool {
+	rv = mustSlice(rv)
+	less, err := ofValueErr(rv, c)
+	if err != nil {
+		panic(err.Error())
+	}
+	return less
+}
+
+// asSlice returns rv as a slice sharing its memory if it's a slice or
+// a non-nil pointer to an array, and whether it was either.
+func asSlice(rv reflect.Value) (reflect.Value, bool) {
+	switch {
+	case rv.Kind() == reflect.Slice:
+		return rv, true
+	case rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Array:
+		arr := rv.Elem()
+		return arr.Slice(0, arr.Len()), true
+	}
+	return rv, false
+}
+
+// mustSlice is like asSlice, but panics if rv is neither a slice nor
+// a pointer to an array.
+func mustSlice(rv reflect.Value) reflect.Value {
+	rv, ok := asSlice(rv)
+	if !ok {
+		if rv.Kind() == reflect.Array {
+			panic(errArray(rv.Type()).Error())
+		}
 		panic("slice argument is not a slice")
 	}
+	return rv
+}
+
+// errArray is the error for an array of type t passed where a slice,
+// or pointer to an array, is expected.
+func errArray(t reflect.Type) error {
+	return fmt.Errorf("lesser: argument of type %v is an array, which is passed by copy; pass a pointer to it", t)
+}
+
+// ofValueErr is like ofValue, but returns an error if the elements of
+// the slice rv can't be ordered.
+func ofValueErr(rv reflect.Value, c *config) (func(i, j int) bool, error) {
+	less := ofValueByElem(rv, c)
+	if c.err != nil {
+		return nil, c.err
+	}
+	valid := c.valid
+	if valid == nil || less == nil {
+		return less, nil
+	}
+	c.use("InvalidLast")
+	return func(i, j int) bool {
+		if vi, vj := valid(i), valid(j); vi != vj {
+			return vi
+		}
+		return less(i, j)
+	}, nil
+}
+
+// ofValueByElem is ofValue without InvalidLast, which doesn't depend
+// on the elements' values.
+func ofValueByElem(rv reflect.Value, c *config) func(i, j int) bool {
+	t := rv.Type()
 	if rv.Len() == 0 {
 		return nil // won't be called
 	}
 	et := t.Elem()
 	addr0 := unsafe.Pointer(rv.Index(0).UnsafeAddr())
-	return forAddr(addr0, et.Size(), 0, et, nil)
+	size := et.Size()
+	if c.derefStable {
+		return bind(c.direct(c.lessDerefStable(rv)), addr0, size)
+	}
+	if c.detAddrs {
+		c.addrRank = c.addrRanks(rv)
+	}
+	var optEq less
+	if tie := c.tieBreak; tie != nil {
+		c.use("TieBreak")
+		if size == 0 {
+			// All elements are equal, and share an address.
+			if c.desc {
+				return func(i, j int) bool { return tie(j, i) }
+			}
+			return tie
+		}
+		optEq = func(a, b unsafe.Pointer) bool {
+			return tie(index(addr0, size, a), index(addr0, size, b))
+		}
+	}
+	if optEq == nil && c.addrRank == nil {
+		return bind(c.cachedLess(et), addr0, size)
+	}
+	return bind(c.lessElem(et, optEq), addr0, size)
+}
+
+// bind returns a less function for the indexes of a slice whose first
+// element is at addr0 and whose elements are size bytes apart,
+// comparing elements with less.
+func bind(less less, addr0 unsafe.Pointer, size uintptr) func(i, j int) bool {
+	return func(i, j int) bool {
+		return less(elem(addr0, size, i), elem(addr0, size, j))
+	}
+}
+
+// forAddr returns a less function comparing the values of type t at
+// offset off from the two addresses it's given. If the values are
+// equal, the result is that of optEq, if non-nil.
+//
+// The path is the dotted name of the struct field being compared,
+// relative to the slice element, or empty for the element itself.
+// Elements of arrays have the path of the array.
+func (c *config) forAddr(off uintptr, t reflect.Type, path string, optEq less) less {
+	if rule, ok := c.fields[path]; ok {
+		if ret := rule.build(c, off, t, path, optEq); ret != nil {
+			c.use(rule.name)
+			return ret
+		}
+	}
+	return c.forType(off, t, path, optEq)
 }
 
-func forAddr(addr0 unsafe.Pointer, size, off uintptr, t reflect.Type, optEq less) less {
-	var makeLess func(addr0 unsafe.Pointer, size, off uintptr, optEq less) less
+// forType is like forAddr, but ignores any field rule for path itself.
+func (c *config) forType(off uintptr, t reflect.Type, path string, optEq less) less {
+	if rule, ok := c.types[t]; ok {
+		c.use(rule.name)
+		return lessCmp(rule.cmp)(off, optEq)
+	}
+	if cmp := registered(t); cmp != nil {
+		return lessCmp(cmp)(off, optEq)
+	}
+	if cmp := cmpMethod(t); cmp != nil {
+		return lessCmp(cmp)(off, optEq)
+	}
+	if cmp := lessMethod(t); cmp != nil {
+		return lessCmp(cmp)(off, optEq)
+	}
+	if cmp := orderedMethod(t); cmp != nil {
+		return lessCmp(cmp)(off, optEq)
+	}
+	if makeLess := c.lessText(t); makeLess != nil {
+		return makeLess(off, optEq)
+	}
+	if makeLess := c.lessStringer(t); makeLess != nil {
+		return makeLess(off, optEq)
+	}
+	if _, _, ok := sqlNull(t); ok {
+		if c.nilsLast {
+			c.use("NilsLast")
+		}
+		return c.lessSQLNull(t, path, c.nilsLast)(off, optEq)
+	}
+	var makeLess func(off uintptr, optEq less) less
 	switch t.Kind() {
 	case reflect.Bool:
 		makeLess = lessBool
@@ -72,58 +403,285 @@ func forAddr(addr0 unsafe.Pointer, size, off uintptr, t reflect.Type, optEq less
 		makeLess = lessUintptr
 	case reflect.Float32:
 		makeLess = lessFloat32
+		if c.totalOrder {
+			makeLess = lessFloat32Total
+			c.use("FloatTotalOrder")
+			break
+		}
+		if c.nanPayloads {
+			makeLess = lessFloat32Payload
+			c.use("OrderNaNPayloads")
+		}
+		if c.floatEps > 0 {
+			makeLess = lessFloat32Grid(c.floatEps)
+			c.use("FloatEpsilon")
+		}
+		if c.nanLast {
+			makeLess = lessNaNLast32(makeLess)
+			c.use("NaNLast")
+		}
 	case reflect.Float64:
 		makeLess = lessFloat64
+		if c.totalOrder {
+			makeLess = lessFloat64Total
+			c.use("FloatTotalOrder")
+			break
+		}
+		if c.nanPayloads {
+			makeLess = lessFloat64Payload
+			c.use("OrderNaNPayloads")
+		}
+		if c.floatEps > 0 {
+			makeLess = lessFloat64Grid(c.floatEps)
+			c.use("FloatEpsilon")
+		}
+		if c.nanLast {
+			makeLess = lessNaNLast64(makeLess)
+			c.use("NaNLast")
+		}
 	case reflect.Complex64:
 		makeLess = lessComplex64
+		if c.complexMag {
+			makeLess = lessComplexMagnitude(func(p unsafe.Pointer) complex128 { return complex128(*(*complex64)(p)) }, c.nanLast)
+			c.use("ComplexByMagnitude")
+			if c.nanLast {
+				c.use("NaNLast")
+			}
+			break
+		}
+		if c.totalOrder {
+			makeLess = func(off uintptr, optEq less) less {
+				return lessFloat32Total(off, lessFloat32Total(off+4, optEq))
+			}
+			c.use("FloatTotalOrder")
+			break
+		}
+		if c.nanLast {
+			part := lessNaNLast32(lessFloat32)
+			makeLess = func(off uintptr, optEq less) less { return part(off, part(off+4, optEq)) }
+			c.use("NaNLast")
+		}
 	case reflect.Complex128:
 		makeLess = lessComplex128
+		if c.complexMag {
+			makeLess = lessComplexMagnitude(func(p unsafe.Pointer) complex128 { return *(*complex128)(p) }, c.nanLast)
+			c.use("ComplexByMagnitude")
+			if c.nanLast {
+				c.use("NaNLast")
+			}
+			break
+		}
+		if c.totalOrder {
+			makeLess = func(off uintptr, optEq less) less {
+				return lessFloat64Total(off, lessFloat64Total(off+8, optEq))
+			}
+			c.use("FloatTotalOrder")
+			break
+		}
+		if c.nanLast {
+			part := lessNaNLast64(lessFloat64)
+			makeLess = func(off uintptr, optEq less) less { return part(off, part(off+8, optEq)) }
+			c.use("NaNLast")
+		}
 	case reflect.Array:
 		ret := optEq
 		et := t.Elem()
 		for i := t.Len() - 1; i >= 0; i-- {
-			ret = forAddr(addr0, size, et.Size()*uintptr(i), et, ret)
+			ret = c.forAddr(off+et.Size()*uintptr(i), et, path, ret)
 		}
-		return ret
+		return orEqual(ret)
 	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.UnsafePointer:
+		if t.Kind() == reflect.Map && c.deepMaps {
+			c.use("DeepMaps")
+			makeLess = c.lessMap(t, path)
+			break
+		}
+		if t.Kind() == reflect.Ptr && (c.deref || c.derefScalars && isScalar(t.Elem())) {
+			if c.deref {
+				c.use("Deref")
+			} else {
+				c.use("DerefScalars")
+			}
+			if c.nilsLast {
+				c.use("NilsLast")
+			}
+			makeLess = c.lessDeref(t, path, c.nilsLast)
+			break
+		}
+		if c.derefStable {
+			// Skip values only orderable by address. The
+			// per-element identity rank breaks any ties.
+			return orEqual(optEq)
+		}
 		makeLess = lessUintptr
+		if c.detAddrs && (t.Kind() == reflect.Chan || t.Kind() == reflect.Func) {
+			makeLess = lessAddrRank(c.addrRank)
+			c.use("DeterministicAddresses")
+		} else if c.strict {
+			return c.addrOrdered(path, t)
+		}
 	case reflect.String:
 		makeLess = lessString
+		var fold func(rune) rune
+		switch {
+		case c.stringCmp != nil:
+			// StringOrder doesn't fold.
+		case c.fold:
+			fold = foldRune
+			c.use("Fold")
+		case c.asciiFold:
+			fold = foldASCIIRune
+			c.use("ASCIIFold")
+		}
+		switch {
+		case c.stringCmp != nil:
+			makeLess = lessStringCmp(c.stringCmp)
+			c.use("StringOrder")
+		case c.ignore != nil:
+			makeLess = lessStringRunes(c.ignore, fold)
+			c.use("StringIgnoring")
+		case c.natural:
+			makeLess = lessStringNatural(fold)
+			c.use("Natural")
+		case c.fold:
+			makeLess = lessStringRunes(nil, fold)
+		case c.asciiFold:
+			makeLess = lessStringASCIIFold
+		}
 	case reflect.Struct:
 		// Walk fields from the back, building up the
 		// tie-breaker chain in reverse.
 		ret := optEq
-		for i := t.NumField() - 1; i >= 0; i-- {
-			sf := t.Field(i)
-			if sf.Name == "_" {
+		fields := c.sortFields(t)
+		for i := len(fields) - 1; i >= 0; i-- {
+			sf := fields[i]
+			if sf.PkgPath != "" {
+				c.use("IncludeUnexported")
+			}
+			fpath := joinPath(path, sf.Name)
+			if fieldDesc(sf) {
+				ret = chainDir(c.forAddr(off+sf.Offset, sf.Type, fpath, nil), true, ret)
 				continue
 			}
-			ret = forAddr(addr0, size, sf.Offset, sf.Type, ret)
+			ret = c.forAddr(off+sf.Offset, sf.Type, fpath, ret)
+		}
+		ret = orEqual(ret)
+		if c.hashOrder && path == "" {
+			c.use("HashOrder")
+			if hash := c.hashFunc(t, path); hash != nil {
+				return lessHashed(hash, off, ret)
+			}
 		}
 		return ret
 	case reflect.Interface:
-		// TODO
+		makeLess = c.lessIface(t, path)
 	case reflect.Slice:
-		// TODO
+		if c.byteSample > 0 && c.plainBytes(t, path) {
+			makeLess = lessSampledBytes(c.byteSample)
+			c.use("SampledBytes")
+		} else if c.sliceBy != "" {
+			makeLess = c.lessSliceBy(t, path)
+		} else if c.plainBytes(t, path) {
+			makeLess = lessBytes
+		} else {
+			makeLess = c.lessSlice(t, path)
+		}
 	}
 	if makeLess == nil {
-		panic(fmt.Sprintf("un-sortable type %v (kind %v)", t, t.Kind()))
+		return c.unsortable(path, t)
 	}
-	return makeLess(addr0, size, off, optEq)
+	return makeLess(off, optEq)
 }
 
-type less func(i, j int) bool
+// orEqual returns less, or if it's nil, as for a struct with no
+// fields to compare and no tie-breaker, a less function reporting
+// that all values are equal.
+func orEqual(less less) less {
+	if less == nil {
+		return func(a, b unsafe.Pointer) bool { return false }
+	}
+	return less
+}
 
-func addr(addr0 unsafe.Pointer, size, off uintptr, i int) unsafe.Pointer {
-	return unsafe.Pointer(uintptr(addr0) + size*uintptr(i) + off)
+// fieldDesc reports whether the struct field sf is tagged
+// `lesser:"desc"`, to order from greatest to least.
+func fieldDesc(sf reflect.StructField) bool {
+	return sf.Tag.Get("lesser") == "desc"
 }
 
-func lessBool(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*bool)(addr(addr0, size, off, i)), *(*bool)(addr(addr0, size, off, j))
+// unsortable records in c that the value of type t at path can't be
+// ordered, as for fail.
+func (c *config) unsortable(path string, t reflect.Type) less {
+	if path == "" {
+		return c.fail(fmt.Errorf("lesser: unsortable type %v (kind %v)", t, t.Kind()))
+	}
+	return c.fail(fmt.Errorf("lesser: field %s: unsortable type %v (kind %v)", path, t, t.Kind()))
+}
+
+// plainBytes reports whether the elements of the slice type t at path
+// are bytes that c orders as plain uint8 values, so the slices can be
+// compared with bytes.Compare.
+func (c *config) plainBytes(t reflect.Type, path string) bool {
+	et := t.Elem()
+	if et.Kind() != reflect.Uint8 || !hasDefaultOrder(et) {
+		return false
+	}
+	if _, ok := c.types[et]; ok {
+		return false
+	}
+	_, ok := c.fields[path] // may apply to the elements
+	return !ok
+}
+
+// sortFields returns the fields of the struct type t that participate
+// in its ordering, in order. Fields tagged `lesser:"-"` are skipped,
+// as are blank (_) fields unless c has the CompareBlankFields option.
+func (c *config) sortFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Tag.Get("lesser") == "-" {
+			continue
+		}
+		if sf.Name == "_" {
+			if !c.compareBlank {
+				continue
+			}
+			c.use("CompareBlankFields")
+		}
+		fields = append(fields, sf)
+	}
+	return fields
+}
+
+// less reports whether the value at a orders before the one at b.
+// Both are typically the addresses of slice elements, with the value
+// being compared at some offset within them.
+type less func(a, b unsafe.Pointer) bool
+
+// at returns the address off bytes past p.
+func at(p unsafe.Pointer, off uintptr) unsafe.Pointer {
+	return unsafe.Pointer(uintptr(p) + off)
+}
+
+// elem returns the address of element i of the slice whose first
+// element is at addr0 and whose elements are size bytes apart.
+func elem(addr0 unsafe.Pointer, size uintptr, i int) unsafe.Pointer {
+	return unsafe.Pointer(uintptr(addr0) + size*uintptr(i))
+}
+
+// index is the inverse of elem, returning the index of the element
+// at p. The size must not be zero.
+func index(addr0 unsafe.Pointer, size uintptr, p unsafe.Pointer) int {
+	return int((uintptr(p) - uintptr(addr0)) / size)
+}
+
+func lessBool(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*bool)(at(a, off)), *(*bool)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -131,12 +689,12 @@ func lessBool(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessString(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*string)(addr(addr0, size, off, i)), *(*string)(addr(addr0, size, off, j))
+func lessString(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*string)(at(a, off)), *(*string)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -144,12 +702,12 @@ func lessString(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessInt(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*int)(addr(addr0, size, off, i)), *(*int)(addr(addr0, size, off, j))
+func lessInt(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*int)(at(a, off)), *(*int)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -157,12 +715,12 @@ func lessInt(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessInt8(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*int8)(addr(addr0, size, off, i)), *(*int8)(addr(addr0, size, off, j))
+func lessInt8(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*int8)(at(a, off)), *(*int8)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -170,12 +728,12 @@ func lessInt8(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessInt16(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*int16)(addr(addr0, size, off, i)), *(*int16)(addr(addr0, size, off, j))
+func lessInt16(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*int16)(at(a, off)), *(*int16)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -183,12 +741,12 @@ func lessInt16(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessInt32(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*int32)(addr(addr0, size, off, i)), *(*int32)(addr(addr0, size, off, j))
+func lessInt32(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*int32)(at(a, off)), *(*int32)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -196,12 +754,12 @@ func lessInt32(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessInt64(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*int64)(addr(addr0, size, off, i)), *(*int64)(addr(addr0, size, off, j))
+func lessInt64(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*int64)(at(a, off)), *(*int64)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -209,12 +767,12 @@ func lessInt64(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessUint(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*uint)(addr(addr0, size, off, i)), *(*uint)(addr(addr0, size, off, j))
+func lessUint(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*uint)(at(a, off)), *(*uint)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -222,12 +780,12 @@ func lessUint(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessUint8(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*uint8)(addr(addr0, size, off, i)), *(*uint8)(addr(addr0, size, off, j))
+func lessUint8(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*uint8)(at(a, off)), *(*uint8)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -235,12 +793,12 @@ func lessUint8(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessUint16(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*uint16)(addr(addr0, size, off, i)), *(*uint16)(addr(addr0, size, off, j))
+func lessUint16(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*uint16)(at(a, off)), *(*uint16)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -248,12 +806,12 @@ func lessUint16(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessUint32(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*uint32)(addr(addr0, size, off, i)), *(*uint32)(addr(addr0, size, off, j))
+func lessUint32(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*uint32)(at(a, off)), *(*uint32)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -261,12 +819,12 @@ func lessUint32(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessUint64(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*uint64)(addr(addr0, size, off, i)), *(*uint64)(addr(addr0, size, off, j))
+func lessUint64(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*uint64)(at(a, off)), *(*uint64)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -274,12 +832,12 @@ func lessUint64(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessUintptr(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*uintptr)(addr(addr0, size, off, i)), *(*uintptr)(addr(addr0, size, off, j))
+func lessUintptr(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*uintptr)(at(a, off)), *(*uintptr)(at(b, off))
 		if va == vb {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -287,12 +845,12 @@ func lessUintptr(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessFloat32(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*float32)(addr(addr0, size, off, i)), *(*float32)(addr(addr0, size, off, j))
-		if va == vb {
+func lessFloat32(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*float32)(at(a, off)), *(*float32)(at(b, off))
+		if va == vb || isNaN32(va) && isNaN32(vb) {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -300,12 +858,12 @@ func lessFloat32(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessFloat64(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return func(i, j int) bool {
-		va, vb := *(*float64)(addr(addr0, size, off, i)), *(*float64)(addr(addr0, size, off, j))
-		if va == vb {
+func lessFloat64(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*float64)(at(a, off)), *(*float64)(at(b, off))
+		if va == vb || math.IsNaN(va) && math.IsNaN(vb) {
 			if optEq != nil {
-				return optEq(i, j)
+				return optEq(a, b)
 			}
 			return false
 		}
@@ -313,12 +871,12 @@ func lessFloat64(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
 	}
 }
 
-func lessComplex64(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return lessFloat32(addr0, size, off, lessFloat32(addr0, size, off+4, optEq))
+func lessComplex64(off uintptr, optEq less) less {
+	return lessFloat32(off, lessFloat32(off+4, optEq))
 }
 
-func lessComplex128(addr0 unsafe.Pointer, size, off uintptr, optEq less) less {
-	return lessFloat64(addr0, size, off, lessFloat64(addr0, size, off+8, optEq))
+func lessComplex128(off uintptr, optEq less) less {
+	return lessFloat64(off, lessFloat64(off+8, optEq))
 }
 
 func isNaN32(f float32) bool { return f != f }
@@ -6,10 +6,13 @@ package lesser
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -124,6 +127,9 @@ func BenchmarkStructSort_lesser_reuse(b *testing.B) {
 	}
 	buf := make([]TStringInt, len(unsorted))
 	b.ResetTimer()
+	// Of only captures buf's backing array, not its contents,
+	// so it's fine that buf is populated later. But buf must
+	// not be reallocated while lesser is in use.
 	lesser := Of(buf)
 	for i := 0; i < b.N; i++ {
 		copy(buf, unsorted)
@@ -138,8 +144,253 @@ func TestStructBlank(t *testing.T) {
 		B int32
 	}
 	a := [6]int32{1, 0, 5, 1, 99, 2}
-	less := forAddr(unsafe.Pointer(&a[0]), 12, 0, reflect.TypeOf(Blank{}), nil)
-	if less(0, 1) {
+	less := newConfig(nil).forAddr(0, reflect.TypeOf(Blank{}), "", nil)
+	if less(unsafe.Pointer(&a[0]), unsafe.Pointer(&a[3])) {
 		t.Errorf("should not be less")
 	}
 }
+
+func TestOfValue(t *testing.T) {
+	s := []TStringInt{{"b", 1}, {"a", 2}, {"a", 1}}
+	rv := reflect.ValueOf(s)
+	sort.Slice(s, OfValue(rv))
+	want := []TStringInt{{"a", 1}, {"a", 2}, {"b", 1}}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("got %v; want %v", s, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-slice Value")
+		}
+	}()
+	OfValue(reflect.ValueOf([3]int{}))
+}
+
+func TestOfSnapshot(t *testing.T) {
+	s := []int{3, 1, 2}
+	less := OfSnapshot(s)
+	if !less(1, 0) || less(0, 1) {
+		t.Error("wrong order")
+	}
+	s = append(s, 0)
+	defer func() {
+		got, _ := recover().(string)
+		want := "lesser: less(3, 0) out of range for snapshot of slice with length 3"
+		if got != want {
+			t.Errorf("panic = %q; want %q", got, want)
+		}
+	}()
+	less(3, 0)
+}
+
+func TestOfPtr(t *testing.T) {
+	s := make([]TStringInt, 0, 2)
+	s = append(s, TStringInt{"b", 1}, TStringInt{"a", 2})
+	less := OfPtr(&s)
+	if !less(1, 0) || less(0, 1) {
+		t.Error("wrong order")
+	}
+	old := &s[0]
+	for i := 0; i < 100; i++ {
+		s = append(s, TStringInt{string(rune('z' - i%26)), i})
+	}
+	if &s[0] == old {
+		t.Fatal("append didn't reallocate")
+	}
+	sort.Slice(s, less)
+	if !sort.SliceIsSorted(s, Of(s)) {
+		t.Errorf("not sorted after growing: %v", s)
+	}
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	s = s[50:]
+	sort.Slice(s, less)
+	if !sort.SliceIsSorted(s, Of(s)) {
+		t.Errorf("not sorted after re-slicing: %v", s)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	type rec struct {
+		S  string
+		F  float64
+		C  complex64
+		N  int8
+		D  uint16 `lesser:"desc"`
+		A  [2]bool
+		T  time.Time
+		Sl []int
+		I  interface{}
+		R  revInt
+	}
+	r := rand.New(rand.NewSource(1))
+	floats := []float64{math.NaN(), math.Inf(-1), -1, 0, math.Copysign(0, -1), 2}
+	ifaces := []interface{}{nil, 1, "x", 2.5}
+	s := make([]rec, 200)
+	for i := range s {
+		s[i] = rec{
+			S:  []string{"", "a", "b"}[r.Intn(3)],
+			F:  floats[r.Intn(len(floats))],
+			C:  complex(float32(r.Intn(2)), float32(floats[r.Intn(len(floats))])),
+			N:  int8(r.Intn(3) - 1),
+			D:  uint16(r.Intn(2)),
+			A:  [2]bool{r.Intn(2) == 0, r.Intn(2) == 0},
+			T:  time.Unix(int64(r.Intn(2)), 0),
+			Sl: make([]int, r.Intn(2)),
+			I:  ifaces[r.Intn(len(ifaces))],
+			R:  revInt{r.Intn(2)},
+		}
+	}
+	less, cmp := Of(s), Compare(s)
+	for i := range s {
+		for j := range s {
+			c := cmp(i, j)
+			if (c < 0) != less(i, j) || (c > 0) != less(j, i) {
+				t.Fatalf("Compare(%v, %v) = %d, but Of gives %v, %v", s[i], s[j], c, less(i, j), less(j, i))
+			}
+		}
+	}
+
+	sorted := []int{1, 3, 5, 7}
+	sorted = append(sorted, 5) // the key
+	cmp = Compare(sorted)
+	key := len(sorted) - 1
+	if i := sort.Search(key, func(i int) bool { return cmp(i, key) >= 0 }); i != 2 || cmp(i, key) != 0 {
+		t.Errorf("search found %d", i)
+	}
+}
+
+func TestBeforeAfter(t *testing.T) {
+	s := []TStringInt{{"a", 2}, {"a", 1}, {"a", 1}}
+	if Before(s, 0, 1) || !Before(s, 1, 0) || Before(s, 1, 2) {
+		t.Error("wrong Before result")
+	}
+	if !After(s, 0, 1) || After(s, 1, 0) || After(s, 1, 2) {
+		t.Error("wrong After result")
+	}
+	defer func() {
+		got, _ := recover().(string)
+		if want := "lesser: index (0, 3) out of range for slice with length 3"; got != want {
+			t.Errorf("panic = %q; want %q", got, want)
+		}
+	}()
+	Before(s, 0, 3)
+}
+
+func TestOfErr(t *testing.T) {
+	s := []TStringInt{{"b", 1}, {"a", 2}}
+	less, err := OfErr(s)
+	if err != nil {
+		t.Fatalf("OfErr: %v", err)
+	}
+	if !less(1, 0) || less(0, 1) {
+		t.Error("wrong order")
+	}
+
+	if _, err := OfErr(3); err == nil || err.Error() != "lesser: argument of type int is not a slice" {
+		t.Errorf("non-slice: err = %v", err)
+	}
+	if _, err := OfOptsErr([]int{1}, DerefStable()); err == nil || err.Error() != "lesser: DerefStable used with non-pointer slice elements of type int" {
+		t.Errorf("DerefStable of ints: err = %v", err)
+	}
+
+	// Every kind of value can be ordered, so exercise the error for
+	// one that can't directly.
+	c := newConfig(nil)
+	c.unsortable("Foo.Bar", reflect.TypeOf(make(chan int)))
+	c.unsortable("Baz", reflect.TypeOf(0))
+	if got, want := fmt.Sprint(c.err), "lesser: field Foo.Bar: unsortable type chan int (kind chan)"; got != want {
+		t.Errorf("err = %q; want %q", got, want)
+	}
+}
+
+func TestArrayPointer(t *testing.T) {
+	buf := [5]TStringInt{{"c", 1}, {"a", 2}, {"b", 0}, {"a", 1}, {"c", 0}}
+	sort.Slice(buf[:], Of(&buf))
+	want := [5]TStringInt{{"a", 1}, {"a", 2}, {"b", 0}, {"c", 0}, {"c", 1}}
+	if buf != want {
+		t.Errorf("got %v; want %v", buf, want)
+	}
+	if !Before(&buf, 0, 4) || Compare(&buf)(2, 2) != 0 {
+		t.Error("Before or Compare disagree with the sorted order")
+	}
+
+	var empty [0]int
+	if _, err := OfErr(&empty); err != nil {
+		t.Errorf("empty array: %v", err)
+	}
+	if _, err := OfErr(buf); err == nil || !strings.Contains(err.Error(), "pass a pointer to it") {
+		t.Errorf("array by value: err = %v", err)
+	}
+	if _, err := OfErr((*[2]int)(nil)); err == nil {
+		t.Error("nil array pointer: no error")
+	}
+}
+
+func TestNothingToCompare(t *testing.T) {
+	type ignored struct {
+		Note string `lesser:"-"`
+		ID   int    `lesser:"-"`
+	}
+	for _, slice := range []interface{}{
+		[]ignored{{"x", 1}, {"y", 2}, {"z", 0}},
+		[]struct{}{{}, {}, {}},
+		[][0]int{{}, {}, {}},
+	} {
+		name := fmt.Sprintf("%T", slice)
+		for _, opts := range [][]Option{nil, {HashOrder()}, {Desc()}} {
+			less := OfOpts(slice, opts...)
+			if less(0, 1) || less(1, 0) {
+				t.Errorf("%s with %d options: elements aren't equal", name, len(opts))
+			}
+		}
+		if c := Compare(slice)(2, 0); c != 0 {
+			t.Errorf("%s: Compare = %d", name, c)
+		}
+		if g := GroupBy(slice); len(g) != 1 {
+			t.Errorf("%s: GroupBy = %v", name, g)
+		}
+	}
+	empty := []struct{}{{}, {}}
+	if n := SortUnique(empty); n != 1 {
+		t.Errorf("SortUnique = %d; want 1", n)
+	}
+}
+
+func TestStructTags(t *testing.T) {
+	type score struct {
+		Team   string
+		Points float64 `lesser:"desc"`
+		Note   string  `lesser:"-"`
+		ID     int
+	}
+	nan := math.NaN()
+	in := []score{
+		{"a", 1, "x", 2},
+		{"b", 5, "", 0},
+		{"a", 3, "y", 1},
+		{"a", nan, "", 0},
+		{"a", 3, "", 0},
+		{"a", 1, "", 1},
+	}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, s := range in {
+		got = append(got, fmt.Sprintf("%s/%v/%d", s.Team, s.Points, s.ID))
+	}
+	// Points descending, NaN (lowest) last, then ID ascending.
+	want := []string{"a/3/0", "a/3/1", "a/1/1", "a/1/2", "a/NaN/0", "b/5/0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	s := []score{{"a", 1, "x", 0}, {"a", 1, "y", 0}}
+	if less := Of(s); less(0, 1) || less(1, 0) {
+		t.Error(`fields tagged lesser:"-" should be ignored`)
+	}
+	if cols := SortColumns(reflect.TypeOf(score{})); !reflect.DeepEqual(cols, []SortColumn{{0, false}, {1, true}, {3, false}}) {
+		t.Errorf("SortColumns = %+v", cols)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// DeepMaps returns an Option that orders maps by their contents rather
+// than by address, so that values containing maps order the same way
+// from run to run. Two maps compare as if each were the list of its
+// entries sorted by key: entry by entry, key first and then value,
+// with a map whose entries are a prefix of another's ordering first.
+// Nil and empty maps are equal, and order before all others.
+//
+// Keys and values are ordered as Of orders values of their types,
+// including the effects of other options. Each comparison sorts the
+// keys of both maps, so DeepMaps suits small maps.
+func DeepMaps() Option {
+	return func(c *config) {
+		c.deepMaps = true
+		c.applied("DeepMaps")
+	}
+}
+
+// lessMap returns the leaf for the map type t under DeepMaps.
+//
+// For recursive types, such as a struct with a field of a map of
+// itself, the value ordering is shared with the inner maps, as
+// described by elemLess.
+func (c *config) lessMap(t reflect.Type, path string) func(off uintptr, optEq less) less {
+	keyLess := c.forAddr(0, t.Key(), path, nil)
+	valLess := c.elemLess(t, func() less { return c.forAddr(0, t.Elem(), path, nil) })
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			ma, mb := reflect.NewAt(t, at(a, off)).Elem(), reflect.NewAt(t, at(b, off)).Elem()
+			if c := cmpMaps(ma, mb, keyLess, *valLess); c != 0 {
+				return c < 0
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// cmpMaps compares the maps ma and mb as described by DeepMaps,
+// ordering their keys by keyLess and their values by valLess. Either
+// may be nil if all values of its type are equal.
+func cmpMaps(ma, mb reflect.Value, keyLess, valLess less) int {
+	ka, kb := sortedMapKeys(ma, keyLess), sortedMapKeys(mb, keyLess)
+	n := ka.Len()
+	if kb.Len() < n {
+		n = kb.Len()
+	}
+	vt := ma.Type().Elem()
+	va, vb := reflect.New(vt).Elem(), reflect.New(vt).Elem()
+	for i := 0; i < n; i++ {
+		if c := cmpWith(keyLess, ka.Index(i), kb.Index(i)); c != 0 {
+			return c
+		}
+		va.Set(ma.MapIndex(ka.Index(i)))
+		vb.Set(mb.MapIndex(kb.Index(i)))
+		if c := cmpWith(valLess, va, vb); c != 0 {
+			return c
+		}
+	}
+	return ka.Len() - kb.Len()
+}
+
+// sortedMapKeys returns the keys of the map m in a new slice, sorted
+// by keyLess.
+func sortedMapKeys(m reflect.Value, keyLess less) reflect.Value {
+	kt := m.Type().Key()
+	keys := reflect.Append(reflect.MakeSlice(reflect.SliceOf(kt), 0, m.Len()), m.MapKeys()...)
+	if keys.Len() > 1 && keyLess != nil {
+		addr0, size := unsafe.Pointer(keys.Pointer()), kt.Size()
+		sort.Slice(keys.Interface(), func(i, j int) bool {
+			return keyLess(elem(addr0, size, i), elem(addr0, size, j))
+		})
+	}
+	return keys
+}
+
+// cmpWith compares the addressable values a and b with less, which
+// may be nil if they're always equal.
+func cmpWith(less less, a, b reflect.Value) int {
+	if less == nil {
+		return 0
+	}
+	pa, pb := unsafe.Pointer(a.UnsafeAddr()), unsafe.Pointer(b.UnsafeAddr())
+	switch {
+	case less(pa, pb):
+		return -1
+	case less(pb, pa):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDeepMaps(t *testing.T) {
+	type doc struct {
+		Tags map[string]int
+		ID   int
+	}
+	in := []doc{
+		{map[string]int{"b": 1}, 1},
+		{map[string]int{"a": 2, "b": 1}, 2},
+		{nil, 3},
+		{map[string]int{"a": 1, "c": 0}, 4},
+		{map[string]int{"a": 1}, 5},
+		{map[string]int{}, 6},
+		{map[string]int{"a": 2, "b": 1}, 0},
+	}
+	sort.Slice(in, OfOpts(in, DeepMaps()))
+	var got []int
+	for _, d := range in {
+		got = append(got, d.ID)
+	}
+	if want := []int{3, 6, 5, 4, 0, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	// Keys and values order like other values, so struct keys
+	// compare field by field and options apply.
+	type point struct{ X, Y int }
+	grids := []map[point]string{
+		{{1, 0}: "a"},
+		{{0, 1}: "B"},
+		{{0, 1}: "a", {0, 0}: "z"},
+	}
+	sort.Slice(grids, OfOpts(grids, DeepMaps(), Fold()))
+	if got, want := fmt.Sprint(grids), "[map[{0 0}:z {0 1}:a] map[{0 1}:B] map[{1 0}:a]]"; got != want {
+		t.Errorf("grids: got %v; want %v", got, want)
+	}
+
+	// ByLength orders by size first, then by contents.
+	sort.Slice(in, OfOpts(in, DeepMaps(), ByLength("Tags")))
+	got = got[:0]
+	for _, d := range in {
+		got = append(got, d.ID)
+	}
+	if want := []int{3, 6, 5, 1, 4, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ByLength: got %v; want %v", got, want)
+	}
+}
+
+func TestDeepMapsRecursive(t *testing.T) {
+	type node struct {
+		Children map[string]node
+	}
+	leaf := node{}
+	in := []node{
+		{map[string]node{"a": {map[string]node{"y": leaf}}}},
+		{map[string]node{"a": leaf}},
+		{map[string]node{"a": {map[string]node{"x": leaf}}}},
+	}
+	want := []node{in[1], in[2], in[0]}
+	sort.Slice(in, OfOpts(in, DeepMaps()))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
+
+func TestDeepMapsValidateOpts(t *testing.T) {
+	type noMaps struct{ A int }
+	if err := ValidateOpts(reflect.TypeOf(noMaps{}), DeepMaps()); err == nil {
+		t.Error("DeepMaps on a type without maps: got nil error")
+	}
+	if err := ValidateOpts(reflect.TypeOf(map[int]int{}), DeepMaps()); err != nil {
+		t.Errorf("DeepMaps on maps: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+var emptyIfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// MergeBy merges the slices a and b, each already sorted by key, and
+// appends the result to the slice that dst points to.
+//
+// The slices a and b may have different element types, as long as
+// both are assignable to the element type of *dst. Typically *dst is
+// a slice of an interface type that both implement, such as
+// []interface{} or []Event, which makes MergeBy useful for combining
+// ordered streams from different sources.
+//
+// The key function returns the key of a[i] when which is 0, and of
+// b[i] when which is 1. Keys are ordered by the rules of Of for a
+// slice of interface{} values, so keys of the same type compare by
+// value. When keys are equal, the element from a comes first.
+//
+// MergeBy panics if dst isn't a pointer to a slice, or if a or b
+// isn't a slice with elements assignable to those of *dst.
+func MergeBy(dst, a, b interface{}, key func(which, i int) interface{}) {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.Elem().Kind() != reflect.Slice {
+		panic(fmt.Sprintf("lesser: MergeBy dst of type %T is not a pointer to a slice", dst))
+	}
+	out := dv.Elem()
+	et := out.Type().Elem()
+	srcArgs := [2]interface{}{a, b}
+	var src [2]reflect.Value
+	for i, arg := range srcArgs {
+		sv := reflect.ValueOf(arg)
+		if sv.Kind() != reflect.Slice || !sv.Type().Elem().AssignableTo(et) {
+			panic(fmt.Sprintf("lesser: MergeBy source of type %T can't be merged into %v", arg, out.Type()))
+		}
+		src[i] = sv
+	}
+
+	less := newConfig(nil).forAddr(0, emptyIfaceType, "", nil)
+	var idx [2]int
+	var keys [2]interface{}
+	if src[0].Len() > 0 {
+		keys[0] = key(0, 0)
+	}
+	if src[1].Len() > 0 {
+		keys[1] = key(1, 0)
+	}
+	for idx[0] < src[0].Len() && idx[1] < src[1].Len() {
+		which := 0
+		if less(unsafe.Pointer(&keys[1]), unsafe.Pointer(&keys[0])) {
+			which = 1
+		}
+		out = reflect.Append(out, src[which].Index(idx[which]))
+		idx[which]++
+		if idx[which] < src[which].Len() {
+			keys[which] = key(which, idx[which])
+		}
+	}
+	for which, sv := range src {
+		for ; idx[which] < sv.Len(); idx[which]++ {
+			out = reflect.Append(out, sv.Index(idx[which]))
+		}
+	}
+	dv.Elem().Set(out)
+}
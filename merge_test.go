@@ -0,0 +1,75 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"testing"
+)
+
+type loginEvent struct {
+	At   int
+	User string
+}
+
+type buildEvent struct {
+	Seq  int64
+	Time int
+}
+
+func TestMergeBy(t *testing.T) {
+	logins := []loginEvent{{1, "a"}, {4, "b"}, {4, "c"}, {9, "d"}}
+	builds := []buildEvent{{100, 0}, {101, 4}, {102, 5}, {103, 10}, {104, 11}}
+	var merged []interface{}
+	MergeBy(&merged, logins, builds, func(which, i int) interface{} {
+		if which == 0 {
+			return logins[i].At
+		}
+		return builds[i].Time
+	})
+	want := []interface{}{
+		buildEvent{100, 0},
+		loginEvent{1, "a"},
+		loginEvent{4, "b"},
+		loginEvent{4, "c"},
+		buildEvent{101, 4},
+		buildEvent{102, 5},
+		loginEvent{9, "d"},
+		buildEvent{103, 10},
+		buildEvent{104, 11},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("wrong:\n got: %v\nwant: %v", merged, want)
+	}
+}
+
+func TestMergeByEmpty(t *testing.T) {
+	dst := []interface{}{"existing"}
+	MergeBy(&dst, []int{}, []string{"x"}, func(which, i int) interface{} { return i })
+	if want := []interface{}{"existing", "x"}; !reflect.DeepEqual(dst, want) {
+		t.Errorf("got %v; want %v", dst, want)
+	}
+}
+
+func TestMergeByBadDst(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	var dst []int
+	MergeBy(&dst, []int{1}, []string{"x"}, func(which, i int) interface{} { return i })
+}
+
+func TestMergeByNilSource(t *testing.T) {
+	defer func() {
+		got, _ := recover().(string)
+		if want := "lesser: MergeBy source of type <nil> can't be merged into []int"; got != want {
+			t.Errorf("panic = %q; want %q", got, want)
+		}
+	}()
+	var dst []int
+	MergeBy(&dst, []int{1}, nil, func(which, i int) interface{} { return i })
+}
@@ -0,0 +1,245 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+var intType = reflect.TypeOf(0)
+
+// cmpMethodNames are the names of three-way comparison methods, in
+// order of preference.
+var cmpMethodNames = []string{"Cmp", "Compare"}
+
+// cmpMethod returns a comparison that calls t's Cmp or, failing that,
+// Compare method, if t has a method Cmp(t) int or *t has a method
+// Cmp(*t) int (and likewise for Compare). Otherwise it returns nil.
+//
+// If t is a pointer type, nil pointers order before all others and
+// the method is only called with non-nil pointers.
+//
+// If t is an interface type, see cmpIfaceMethod.
+func cmpMethod(t reflect.Type) cmpFunc {
+	for _, name := range cmpMethodNames {
+		if cmp := cmpNamedMethod(t, name); cmp != nil {
+			return cmp
+		}
+	}
+	return nil
+}
+
+// cmpNamedMethod is like cmpMethod, but only for the method called
+// name.
+func cmpNamedMethod(t reflect.Type, name string) cmpFunc {
+	if t.Kind() == reflect.Interface {
+		return cmpIfaceMethod(t, name)
+	}
+	if m, ok := t.MethodByName(name); ok && isCmpMethod(m.Type, t) {
+		fn := m.Func
+		cmp := func(a, b unsafe.Pointer) int {
+			return callCmp(fn, reflect.NewAt(t, a).Elem(), reflect.NewAt(t, b).Elem())
+		}
+		if t.Kind() == reflect.Ptr {
+			return nilFirst(cmp)
+		}
+		return cmp
+	}
+	pt := reflect.PtrTo(t)
+	if m, ok := pt.MethodByName(name); ok && isCmpMethod(m.Type, pt) {
+		fn := m.Func
+		return func(a, b unsafe.Pointer) int {
+			return callCmp(fn, reflect.NewAt(t, a), reflect.NewAt(t, b))
+		}
+	}
+	return nil
+}
+
+// lessMethod returns a comparison that calls t's Less method, if t has
+// a method Less(t) bool or *t has a method Less(*t) bool. Otherwise
+// it returns nil. Less is called in both directions to tell "after"
+// from "equal", so it must define a strict weak ordering. As with
+// cmpMethod, nil pointers of a pointer type t order first.
+func lessMethod(t reflect.Type) cmpFunc {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if m, ok := t.MethodByName("Less"); ok && isLessMethod(m.Type, t) {
+		fn := m.Func
+		cmp := func(a, b unsafe.Pointer) int {
+			return callLess(fn, reflect.NewAt(t, a).Elem(), reflect.NewAt(t, b).Elem())
+		}
+		if t.Kind() == reflect.Ptr {
+			return nilFirst(cmp)
+		}
+		return cmp
+	}
+	pt := reflect.PtrTo(t)
+	if m, ok := pt.MethodByName("Less"); ok && isLessMethod(m.Type, pt) {
+		fn := m.Func
+		return func(a, b unsafe.Pointer) int {
+			return callLess(fn, reflect.NewAt(t, a), reflect.NewAt(t, b))
+		}
+	}
+	return nil
+}
+
+// Ordered is implemented by types that define their own ordering.
+// Of orders values of such types, wherever they appear, by calling
+// LessThan instead of comparing them structurally.
+//
+// The other argument always holds a value of the same type as the
+// receiver. If only *T implements Ordered, values of type T are
+// compared by calling LessThan on their addresses, with other
+// holding a *T.
+//
+// LessThan must define a strict weak ordering, as for sort.Slice. A
+// Cmp, Compare or Less method (see Of) takes precedence over
+// LessThan.
+type Ordered interface {
+	LessThan(other interface{}) bool
+}
+
+var orderedType = reflect.TypeOf((*Ordered)(nil)).Elem()
+
+// orderedMethod returns a comparison that calls the LessThan method
+// of t or *t, if either implements Ordered. Otherwise it returns nil.
+// As with cmpMethod, nil pointers of a pointer type t order first.
+// Interface types are handled per dynamic type by lessIface.
+func orderedMethod(t reflect.Type) cmpFunc {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if t.Implements(orderedType) {
+		cmp := func(a, b unsafe.Pointer) int {
+			return cmpOrdered(reflect.NewAt(t, a).Elem(), reflect.NewAt(t, b).Elem())
+		}
+		if t.Kind() == reflect.Ptr {
+			return nilFirst(cmp)
+		}
+		return cmp
+	}
+	if reflect.PtrTo(t).Implements(orderedType) {
+		return func(a, b unsafe.Pointer) int {
+			return cmpOrdered(reflect.NewAt(t, a), reflect.NewAt(t, b))
+		}
+	}
+	return nil
+}
+
+// cmpOrdered compares a and b, whose type implements Ordered.
+func cmpOrdered(a, b reflect.Value) int {
+	oa, ob := a.Interface().(Ordered), b.Interface().(Ordered)
+	switch {
+	case oa.LessThan(ob):
+		return -1
+	case ob.LessThan(oa):
+		return 1
+	}
+	return 0
+}
+
+// isCmpMethod reports whether mt, the type of a method expression with
+// receiver type t, is func(t, t) int.
+func isCmpMethod(mt, t reflect.Type) bool {
+	return mt.NumIn() == 2 && mt.In(1) == t &&
+		mt.NumOut() == 1 && mt.Out(0) == intType
+}
+
+// isLessMethod reports whether mt, the type of a method expression
+// with receiver type t, is func(t, t) bool.
+func isLessMethod(mt, t reflect.Type) bool {
+	return mt.NumIn() == 2 && mt.In(1) == t &&
+		mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Bool
+}
+
+// cmpIfaceMethod returns a comparison that calls the method name(t)
+// int of the interface type t, such as Cmp(t) int, or nil if t has no
+// such method. The method
+// is dispatched at run time on each value's dynamic type, so a
+// variety of concrete types can share an ordering.
+//
+// Nil interface values order first, then interface values holding
+// nil pointers (or other nil dynamic values), ordered by the name of
+// their dynamic type. The method is only called when both values are
+// non-nil.
+func cmpIfaceMethod(t reflect.Type, name string) cmpFunc {
+	m, ok := t.MethodByName(name)
+	if !ok {
+		return nil
+	}
+	if mt := m.Type; mt.NumIn() != 1 || mt.In(0) != t || mt.NumOut() != 1 || mt.Out(0) != intType {
+		return nil
+	}
+	return func(a, b unsafe.Pointer) int {
+		va, vb := reflect.NewAt(t, a).Elem(), reflect.NewAt(t, b).Elem()
+		ra, rb := nilRank(va), nilRank(vb)
+		switch {
+		case ra != rb:
+			return ra - rb
+		case ra == ifaceNil:
+			return 0
+		case ra == ifaceNilValue:
+			return strings.Compare(va.Elem().Type().String(), vb.Elem().Type().String())
+		}
+		return int(va.Method(m.Index).Call([]reflect.Value{vb})[0].Int())
+	}
+}
+
+// Ranks returned by nilRank, in order.
+const (
+	ifaceNil      = iota // nil interface value
+	ifaceNilValue        // non-nil interface holding a nil value
+	ifaceNonNil
+)
+
+// nilRank classifies v, a value of interface kind.
+func nilRank(v reflect.Value) int {
+	if v.IsNil() {
+		return ifaceNil
+	}
+	switch e := v.Elem(); e.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		if e.IsNil() {
+			return ifaceNilValue
+		}
+	}
+	return ifaceNonNil
+}
+
+func callCmp(fn, a, b reflect.Value) int {
+	return int(fn.Call([]reflect.Value{a, b})[0].Int())
+}
+
+// callLess compares a and b with fn, a Less method expression.
+func callLess(fn, a, b reflect.Value) int {
+	switch {
+	case fn.Call([]reflect.Value{a, b})[0].Bool():
+		return -1
+	case fn.Call([]reflect.Value{b, a})[0].Bool():
+		return 1
+	}
+	return 0
+}
+
+// nilFirst wraps cmp, a comparison of pointer-shaped values, so that
+// nil values order before all non-nil ones and cmp only sees non-nil
+// values.
+func nilFirst(cmp cmpFunc) cmpFunc {
+	return func(a, b unsafe.Pointer) int {
+		na, nb := *(*unsafe.Pointer)(a) == nil, *(*unsafe.Pointer)(b) == nil
+		switch {
+		case na && nb:
+			return 0
+		case na:
+			return -1
+		case nb:
+			return 1
+		}
+		return cmp(a, b)
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// revInt orders in reverse via its Cmp method, so structural
+// comparison would give the wrong answer.
+type revInt struct{ V int }
+
+func (a revInt) Cmp(b revInt) int { return b.V - a.V }
+
+type withRevInt struct {
+	R revInt
+	S string
+}
+
+func TestCmpMethod(t *testing.T) {
+	in := []withRevInt{{revInt{1}, "b"}, {revInt{3}, "a"}, {revInt{1}, "a"}, {revInt{2}, "z"}}
+	want := []withRevInt{{revInt{3}, "a"}, {revInt{2}, "z"}, {revInt{1}, "a"}, {revInt{1}, "b"}}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
+
+func TestCmpMethodBigInt(t *testing.T) {
+	s := []*big.Int{big.NewInt(10), nil, big.NewInt(-5), big.NewInt(2), nil}
+	sort.Slice(s, Of(s))
+	got := fmt.Sprint(s)
+	if want := "[<nil> <nil> -5 2 10]"; got != want {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestCmpMethodPointerReceiver(t *testing.T) {
+	// big.Int's Cmp has a pointer receiver, so a []big.Int uses
+	// (*big.Int).Cmp on the elements' addresses.
+	s := make([]big.Int, 3)
+	s[0].SetInt64(7)
+	s[1].SetInt64(-7)
+	s[2].SetString("100000000000000000000000", 10)
+	sort.Slice(s, Of(s))
+	if s[0].Int64() != -7 || s[1].Int64() != 7 || s[2].BitLen() < 64 {
+		t.Errorf("wrong order: %v, %v, %v", &s[0], &s[1], &s[2])
+	}
+}
+
+func TestCmpMethodBigRatFloat(t *testing.T) {
+	rats := make([]big.Rat, 3)
+	rats[0].SetFrac64(2, 3)
+	rats[1].SetFrac64(-1, 2)
+	rats[2].SetFrac64(1, 3)
+	sort.Slice(rats, Of(rats))
+	if got, want := fmt.Sprintln(rats[0].String(), rats[1].String(), rats[2].String()), "-1/2 1/3 2/3\n"; got != want {
+		t.Errorf("rats: got %v; want %v", got, want)
+	}
+
+	// A precision wider than float64's tells these apart.
+	eps := new(big.Float).SetPrec(200).SetMantExp(big.NewFloat(1), -100)
+	one := new(big.Float).SetPrec(200).SetInt64(1)
+	floats := []*big.Float{new(big.Float).Add(one, eps), nil, one, new(big.Float).Neg(eps)}
+	sort.Slice(floats, Of(floats))
+	if floats[0] != nil || floats[1].Sign() >= 0 || floats[2] != one || floats[3].Cmp(one) <= 0 {
+		t.Errorf("floats: wrong order %v", floats)
+	}
+
+	type account struct {
+		Balance *big.Int
+		ID      int
+	}
+	huge, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	accts := []account{{huge, 1}, {big.NewInt(5), 2}, {big.NewInt(5), 0}}
+	sort.Slice(accts, Of(accts))
+	if accts[0].ID != 0 || accts[1].ID != 2 || accts[2].ID != 1 {
+		t.Errorf("accounts: wrong order %v", accts)
+	}
+}
+
+// Animal is an interface whose implementations share an ordering
+// through its Cmp method, regardless of their concrete types.
+type Animal interface {
+	Name() string
+	Cmp(Animal) int
+}
+
+type Dog struct{ DogName string }
+type Cat struct {
+	Lives   int
+	CatName string
+}
+
+func (d *Dog) Name() string { return d.DogName }
+func (c *Cat) Name() string { return c.CatName }
+
+func (d *Dog) Cmp(o Animal) int { return strings.Compare(d.Name(), o.Name()) }
+func (c *Cat) Cmp(o Animal) int { return strings.Compare(c.Name(), o.Name()) }
+
+func TestCmpMethodInterface(t *testing.T) {
+	var nilDog *Dog
+	var nilCat *Cat
+	in := []Animal{
+		&Dog{"rex"},
+		nilCat,
+		&Cat{9, "felix"},
+		nil,
+		&Dog{"ace"},
+		nilDog,
+		&Cat{1, "zelda"},
+		nil,
+	}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, a := range in {
+		switch {
+		case a == nil:
+			got = append(got, "nil")
+		case reflect.ValueOf(a).IsNil():
+			got = append(got, fmt.Sprintf("%T(nil)", a))
+		default:
+			got = append(got, a.Name())
+		}
+	}
+	want := []string{"nil", "nil", "*lesser.Cat(nil)", "*lesser.Dog(nil)", "ace", "felix", "rex", "zelda"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestCmpMethodInterfaceField(t *testing.T) {
+	type pet struct {
+		A     Animal
+		Owner string
+	}
+	in := []pet{{&Cat{1, "b"}, "y"}, {&Dog{"b"}, "x"}, {nil, "z"}, {&Dog{"a"}, "w"}}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, p := range in {
+		got = append(got, p.Owner)
+	}
+	want := []string{"z", "w", "x", "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+// version orders by its numeric components via LessThan, where
+// structural comparison of the string would put "10" before "9".
+type version struct{ S string }
+
+func (v version) LessThan(other interface{}) bool {
+	a, b := strings.Split(v.S, "."), strings.Split(other.(version).S, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if len(a[i]) != len(b[i]) {
+			return len(a[i]) < len(b[i])
+		}
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// byLen implements Ordered on its pointer type only.
+type byLen struct{ S string }
+
+func (p *byLen) LessThan(other interface{}) bool { return len(p.S) < len(other.(*byLen).S) }
+
+func TestOrdered(t *testing.T) {
+	type release struct {
+		V    version
+		Name string
+	}
+	in := []release{{version{"1.10"}, "a"}, {version{"1.9"}, "b"}, {version{"1.9"}, "a"}, {version{"1"}, "z"}}
+	want := []release{{version{"1"}, "z"}, {version{"1.9"}, "a"}, {version{"1.9"}, "b"}, {version{"1.10"}, "a"}}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+
+	lens := []byLen{{"ccc"}, {"a"}, {"zz"}}
+	sort.Slice(lens, Of(lens))
+	if want := []byLen{{"a"}, {"zz"}, {"ccc"}}; !reflect.DeepEqual(lens, want) {
+		t.Errorf("got %v; want %v", lens, want)
+	}
+
+	ptrs := []*byLen{{"ccc"}, nil, {"a"}}
+	sort.Slice(ptrs, Of(ptrs))
+	if ptrs[0] != nil || ptrs[1].S != "a" || ptrs[2].S != "ccc" {
+		t.Errorf("wrong pointer order: %v, %v, %v", ptrs[0], ptrs[1], ptrs[2])
+	}
+
+	ifaces := []interface{}{version{"2.0"}, version{"10.0"}, version{"3"}}
+	sort.Slice(ifaces, Of(ifaces))
+	if want := []interface{}{version{"2.0"}, version{"3"}, version{"10.0"}}; !reflect.DeepEqual(ifaces, want) {
+		t.Errorf("got %v; want %v", ifaces, want)
+	}
+}
+
+// urgency orders by Level, highest first, via its Less method.
+type urgency struct {
+	Level int
+	Note  string // not part of the ordering
+}
+
+func (p urgency) Less(q urgency) bool { return p.Level > q.Level }
+
+// shortest orders by length via a Less method on its pointer type.
+type shortest struct{ S string }
+
+func (a *shortest) Less(b *shortest) bool { return len(a.S) < len(b.S) }
+
+func TestLessMethod(t *testing.T) {
+	type task struct {
+		P    urgency
+		Name string
+	}
+	in := []task{{urgency{1, "x"}, "b"}, {urgency{3, "y"}, "c"}, {urgency{1, "a"}, "a"}}
+	want := []task{in[1], in[2], in[0]}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+
+	strs := []shortest{{"ccc"}, {"a"}, {"zz"}}
+	sort.Slice(strs, Of(strs))
+	if want := []shortest{{"a"}, {"zz"}, {"ccc"}}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("got %v; want %v", strs, want)
+	}
+
+	ptrs := []*shortest{{"ccc"}, nil, {"a"}}
+	sort.Slice(ptrs, Of(ptrs))
+	if ptrs[0] != nil || ptrs[1].S != "a" || ptrs[2].S != "ccc" {
+		t.Errorf("wrong pointer order: %v, %v, %v", ptrs[0], ptrs[1], ptrs[2])
+	}
+
+	ifaces := []interface{}{urgency{1, ""}, urgency{5, ""}}
+	sort.Slice(ifaces, Of(ifaces))
+	if ifaces[0].(urgency).Level != 5 {
+		t.Errorf("interfaces: got %v", ifaces)
+	}
+
+	if cols := SortColumns(reflect.TypeOf(urgency{})); cols != nil {
+		t.Errorf("SortColumns = %v; want nil", cols)
+	}
+}
+
+// versionTriple orders by numeric version components via its Compare
+// method, which structural comparison of its fields would get wrong.
+type versionTriple struct{ Patch, Minor, Major int }
+
+func (a versionTriple) Compare(b versionTriple) int {
+	if a.Major != b.Major {
+		return a.Major - b.Major
+	}
+	if a.Minor != b.Minor {
+		return a.Minor - b.Minor
+	}
+	return a.Patch - b.Patch
+}
+
+// both has Cmp and Compare methods that disagree.
+type both struct{ V int }
+
+func (a both) Cmp(b both) int     { return a.V - b.V }
+func (a both) Compare(b both) int { return b.V - a.V }
+
+// Shape is an interface whose implementations share an ordering
+// through its Compare method.
+type Shape interface {
+	Area() float64
+	Compare(Shape) int
+}
+
+type square struct{ Side float64 }
+
+func (s square) Area() float64 { return s.Side * s.Side }
+func (s square) Compare(o Shape) int {
+	switch a, b := s.Area(), o.Area(); {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func TestCompareMethod(t *testing.T) {
+	type release struct {
+		V    versionTriple
+		Name string
+	}
+	in := []release{{versionTriple{0, 10, 1}, "b"}, {versionTriple{3, 2, 1}, "a"}, {versionTriple{0, 10, 1}, "a"}}
+	want := []release{in[1], in[2], in[0]}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+
+	bs := []both{{2}, {1}, {3}}
+	sort.Slice(bs, Of(bs))
+	if want := []both{{1}, {2}, {3}}; !reflect.DeepEqual(bs, want) {
+		t.Errorf("Cmp should win over Compare: got %v", bs)
+	}
+
+	shapes := []Shape{square{3}, nil, square{1}, square{2}}
+	sort.Slice(shapes, Of(shapes))
+	if want := []Shape{nil, square{1}, square{2}, square{3}}; !reflect.DeepEqual(shapes, want) {
+		t.Errorf("got %v; want %v", shapes, want)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"unsafe"
+)
+
+// RegisterNetPrefix registers net.IPNet and *net.IPNet, and with Go
+// 1.18 and later netip.Prefix, to order like the entries of a routing
+// table: by network address, with IPv4 networks before IPv6 ones, and
+// then by prefix length. If longestFirst is true, more specific
+// (longer) prefixes of the same network order first, as in longest
+// prefix matching; otherwise shorter prefixes do.
+//
+// Addresses are masked to their network before comparing, so
+// 10.1.2.3/8 and 10.0.0.0/8 compare by their remaining host bits
+// only after all else is equal. Nil pointers and invalid prefixes
+// order first. An IPv4-mapped IPv6 network with a 16-byte mask, such
+// as ::ffff:10.0.0.0/104, is an IPv6 network.
+//
+// Calling RegisterNetPrefix again replaces the earlier ordering.
+func RegisterNetPrefix(longestFirst bool) {
+	cmp := func(a, b *net.IPNet) int { return cmpIPNet(a, b, longestFirst) }
+	register(reflect.TypeOf(net.IPNet{}), func(a, b unsafe.Pointer) int {
+		return cmp((*net.IPNet)(a), (*net.IPNet)(b))
+	})
+	register(reflect.TypeOf((*net.IPNet)(nil)), nilFirst(func(a, b unsafe.Pointer) int {
+		return cmp(*(**net.IPNet)(a), *(**net.IPNet)(b))
+	}))
+	registerNetip(longestFirst)
+}
+
+// ipFamily returns the family number of an address of length n: 4
+// or 6, or 0 for an invalid address.
+func ipFamily(n int) int {
+	switch n {
+	case net.IPv4len:
+		return 4
+	case net.IPv6len:
+		return 6
+	}
+	return 0
+}
+
+// cmpIPNet compares a and b as described by RegisterNetPrefix.
+func cmpIPNet(a, b *net.IPNet, longestFirst bool) int {
+	na, nb := ipNetwork(a), ipNetwork(b)
+	oa, _ := a.Mask.Size()
+	ob, _ := b.Mask.Size()
+	if c := cmpPrefix(ipFamily(len(na)), na, oa, ipFamily(len(nb)), nb, ob, longestFirst); c != 0 {
+		return c
+	}
+	// Non-canonical masks, whose Size is 0.
+	if c := bytes.Compare(a.Mask, b.Mask); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.IP.To16(), b.IP.To16())
+}
+
+// ipNetwork returns the masked network address of n, of the length of
+// its mask, or nil if n is invalid.
+func ipNetwork(n *net.IPNet) net.IP {
+	ip := n.IP.To16()
+	if len(n.Mask) == net.IPv4len {
+		ip = n.IP.To4()
+	}
+	if ip == nil || ipFamily(len(n.Mask)) == 0 {
+		return nil
+	}
+	return ip.Mask(n.Mask)
+}
+
+// cmpPrefix compares two network prefixes, each given by its address
+// family, network address bytes and prefix length.
+func cmpPrefix(fa int, na []byte, la int, fb int, nb []byte, lb int, longestFirst bool) int {
+	if fa != fb {
+		return fa - fb
+	}
+	if c := bytes.Compare(na, nb); c != 0 {
+		return c
+	}
+	if longestFirst {
+		la, lb = lb, la
+	}
+	return la - lb
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package lesser
+
+import (
+	"net/netip"
+	"reflect"
+	"unsafe"
+)
+
+// registerNetip registers netip.Prefix for RegisterNetPrefix.
+func registerNetip(longestFirst bool) {
+	register(reflect.TypeOf(netip.Prefix{}), func(a, b unsafe.Pointer) int {
+		pa, pb := *(*netip.Prefix)(a), *(*netip.Prefix)(b)
+		fa, na := netipNetwork(pa)
+		fb, nb := netipNetwork(pb)
+		if c := cmpPrefix(fa, na, pa.Bits(), fb, nb, pb.Bits(), longestFirst); c != 0 {
+			return c
+		}
+		return pa.Addr().Compare(pb.Addr())
+	})
+}
+
+// netipNetwork returns the address family of p, as for ipFamily, and
+// its masked network address.
+func netipNetwork(p netip.Prefix) (family int, network []byte) {
+	if !p.IsValid() {
+		return 0, nil
+	}
+	if p.Addr().Is4() {
+		b := p.Masked().Addr().As4()
+		return 4, b[:]
+	}
+	b := p.Masked().Addr().As16()
+	return 6, b[:]
+}
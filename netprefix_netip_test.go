@@ -0,0 +1,57 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package lesser
+
+import (
+	"net/netip"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRegisterNetPrefixNetip(t *testing.T) {
+	defer saveRegistry()()
+	RegisterNetPrefix(true)
+	in := []netip.Prefix{
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("10.1.2.3/8"),
+		netip.MustParsePrefix("2001:db8::/48"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+		{},
+		netip.MustParsePrefix("10.0.0.0/24"),
+	}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, p := range in {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"invalid Prefix",
+		"10.0.0.0/24",
+		"10.0.0.0/8",
+		"10.1.2.3/8",
+		"2001:db8::/48",
+		"2001:db8::/32",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestRegisterNetPrefixRestored(t *testing.T) {
+	// The default ordering of netip.Prefix, by address and then
+	// bits, is back after TestRegisterNetPrefixNetip.
+	func() {
+		defer saveRegistry()()
+		RegisterNetPrefix(true)
+	}()
+	in := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24"), netip.MustParsePrefix("10.0.0.0/8")}
+	sort.Slice(in, Of(in))
+	if in[0].Bits() != 8 {
+		t.Errorf("got %v; want the default ordering, shorter prefix first", in)
+	}
+}
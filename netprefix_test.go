@@ -0,0 +1,138 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// saveRegistry returns a function that restores the package registry
+// to its current entries, for tests that register orderings, which
+// would otherwise affect the tests that follow.
+func saveRegistry() (restore func()) {
+	registryMu.Lock()
+	saved := make(map[reflect.Type]cmpFunc, len(registry))
+	for t, cmp := range registry {
+		saved[t] = cmp
+	}
+	registryMu.Unlock()
+	return func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		registry = saved
+		// Orderings cached with the test's registrations are
+		// out of date again.
+		registryGen++
+	}
+}
+
+func mustCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRegisterNetPrefix(t *testing.T) {
+	defer saveRegistry()()
+	in := []string{
+		"2001:db8::/48",
+		"10.0.0.0/8",
+		"10.0.0.0/16",
+		"192.168.1.0/24",
+		"2001:db8::/32",
+		"::/0",
+		"0.0.0.0/0",
+		"10.1.0.0/16",
+	}
+	tests := []struct {
+		longestFirst bool
+		want         []string
+	}{
+		{true, []string{
+			"0.0.0.0/0",
+			"10.0.0.0/16",
+			"10.0.0.0/8",
+			"10.1.0.0/16",
+			"192.168.1.0/24",
+			"::/0",
+			"2001:db8::/48",
+			"2001:db8::/32",
+		}},
+		{false, []string{
+			"0.0.0.0/0",
+			"10.0.0.0/8",
+			"10.0.0.0/16",
+			"10.1.0.0/16",
+			"192.168.1.0/24",
+			"::/0",
+			"2001:db8::/32",
+			"2001:db8::/48",
+		}},
+	}
+	for _, tt := range tests {
+		RegisterNetPrefix(tt.longestFirst)
+
+		var ptrs []*net.IPNet
+		var vals []net.IPNet
+		for _, s := range in {
+			ptrs = append(ptrs, mustCIDR(t, s))
+			vals = append(vals, *mustCIDR(t, s))
+		}
+		ptrs = append(ptrs, nil)
+		sort.Slice(ptrs, Of(ptrs))
+		sort.Slice(vals, Of(vals))
+
+		if ptrs[0] != nil {
+			t.Errorf("longestFirst=%v: nil not first: %v", tt.longestFirst, ptrs)
+			continue
+		}
+		var gotPtrs, gotVals []string
+		for i := range vals {
+			gotPtrs = append(gotPtrs, ptrs[i+1].String())
+			gotVals = append(gotVals, vals[i].String())
+		}
+		if !reflect.DeepEqual(gotPtrs, tt.want) {
+			t.Errorf("longestFirst=%v, pointers:\n got %q\nwant %q", tt.longestFirst, gotPtrs, tt.want)
+		}
+		if !reflect.DeepEqual(gotVals, tt.want) {
+			t.Errorf("longestFirst=%v, values:\n got %q\nwant %q", tt.longestFirst, gotVals, tt.want)
+		}
+	}
+}
+
+func TestCmpIPNetHostBits(t *testing.T) {
+	a := &net.IPNet{IP: net.ParseIP("10.1.2.3"), Mask: net.CIDRMask(8, 32)}
+	b := mustCIDR(t, "10.0.0.0/8")
+	c := mustCIDR(t, "9.255.0.0/16")
+	if got := cmpIPNet(b, a, true); got >= 0 {
+		t.Errorf("host bits: cmpIPNet = %d; want < 0", got)
+	}
+	if got := cmpIPNet(c, a, true); got >= 0 {
+		t.Errorf("network before host bits: cmpIPNet = %d; want < 0", got)
+	}
+	if got := cmpIPNet(&net.IPNet{}, c, true); got >= 0 {
+		t.Errorf("invalid: cmpIPNet = %d; want < 0", got)
+	}
+}
+
+func TestSaveRegistry(t *testing.T) {
+	ipNet := reflect.TypeOf(net.IPNet{})
+	before := registered(ipNet)
+	restore := saveRegistry()
+	RegisterNetPrefix(true)
+	if registered(ipNet) == nil {
+		t.Fatal("RegisterNetPrefix didn't register net.IPNet")
+	}
+	restore()
+	if (registered(ipNet) == nil) != (before == nil) {
+		t.Error("restore didn't undo RegisterNetPrefix")
+	}
+}
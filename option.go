@@ -0,0 +1,252 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// An Option modifies the ordering rules used by OfOpts.
+type Option func(*config)
+
+// config is the set of ordering rules in effect while building a less
+// function.
+type config struct {
+	byteSample int      // if non-zero, see SampledBytes
+	floatEps   float64  // if non-zero, see FloatEpsilon
+	ignore     *runeSet // if non-nil, see StringIgnoring
+	sliceBy    string   // "SliceByMin" or "SliceByMax", if set
+	fold       bool     // see Fold
+	asciiFold  bool     // see ASCIIFold
+	natural    bool     // see Natural
+	desc       bool     // see Desc
+
+	stringCmp func(a, b string) int // if non-nil, see StringOrder
+
+	nanPayloads bool // see OrderNaNPayloads
+	nanLast     bool // see NaNLast
+	totalOrder  bool // see FloatTotalOrder
+	complexMag  bool // see ComplexByMagnitude
+
+	derefStable  bool // see DerefStable
+	derefScalars bool // see DerefScalars
+	deref        bool // see Deref
+	nilsLast     bool // see NilsLast
+	compareBlank bool // see CompareBlankFields
+	hashOrder    bool // see HashOrder
+	numericIface bool // see NumericInterfaceOrder
+	deepMaps     bool // see DeepMaps
+
+	stringerFallback bool // see StringerFallback
+	byText           bool // see ByText
+
+	// detAddrs is set by DeterministicAddresses, and addrRank by
+	// ofValue for the slice being ordered.
+	detAddrs bool
+	addrRank map[unsafe.Pointer]int
+
+	strict bool // see Strict
+
+	tieBreak func(i, j int) bool // if non-nil, see TieBreak
+	valid    func(i int) bool    // if non-nil, see InvalidLast
+
+	fields map[string]fieldRule // keyed by field path
+	types  map[reflect.Type]typeRule
+
+	// building holds the element orderings of the slice and
+	// pointer types whose orderings are being built, keyed by those
+	// types. See lessSlice.
+	building map[reflect.Type]*less
+
+	// derefGuard is set while building the ordering of a pointer
+	// type under Deref or DerefScalars. See lessDeref.
+	derefGuard *derefGuard
+
+	// nestedDyn is shared by the orderings built at run time for
+	// interfaces' dynamic types, so the interfaces within them reuse
+	// those orderings rather than building them anew at each level
+	// of a recursive value. See lessIface.
+	nestedDyn *sync.Map
+
+	// names lists the options that were applied, and used records
+	// those that affected how some value is compared. See
+	// ValidateOpts.
+	names []string
+	used  map[string]bool
+
+	// key encodes the applied options and their arguments, in
+	// order, for caching, unless uncacheable is set by an option
+	// whose arguments can't be encoded. See cachedLess.
+	key         []string
+	uncacheable bool
+
+	withCache bool // see WithCache
+
+	// err is the first reason found that the ordering being built
+	// can't be used. See OfErr.
+	err error
+}
+
+func newConfig(opts []Option) *config {
+	c := new(config)
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// applied records that the option name was applied to c with the
+// given arguments. The arguments' %#v forms, along with the name,
+// must identify the option's effect, since they're used to share
+// orderings built with equivalent options.
+func (c *config) applied(name string, args ...interface{}) {
+	c.names = append(c.names, name)
+	c.key = append(c.key, fmt.Sprintf("%s%#v", name, args))
+}
+
+// fail records err as the reason the ordering being built can't be
+// used, unless an earlier one was recorded, and returns a less
+// function to stand in for the part that couldn't be built. Orderings
+// built with errors mustn't be used; the stand-in panics if called.
+func (c *config) fail(err error) less {
+	if c.err == nil {
+		c.err = err
+	}
+	return func(a, b unsafe.Pointer) bool { panic(err.Error()) }
+}
+
+// use records that the option name affected the ordering being built.
+func (c *config) use(name string) {
+	if len(c.names) == 0 {
+		return
+	}
+	if c.used == nil {
+		c.used = make(map[string]bool)
+	}
+	c.used[name] = true
+}
+
+// ValidateOpts reports an error if any of opts would have no effect on
+// the ordering of values of type t, such as FloatEpsilon for a type
+// with no float fields. This catches misconfigured orderings early.
+//
+// ValidateOpts panics if values of type t can't be ordered, like Of.
+func ValidateOpts(t reflect.Type, opts ...Option) error {
+	c := newConfig(opts)
+	c.lessElem(t, nil)
+	if c.err != nil {
+		panic(c.err.Error())
+	}
+	var unused []string
+	for _, name := range c.names {
+		if !c.used[name] {
+			unused = append(unused, name)
+		}
+	}
+	switch len(unused) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("lesser: option %s does not apply to type %v", unused[0], t)
+	}
+	return fmt.Errorf("lesser: options %s do not apply to type %v", strings.Join(unused, ", "), t)
+}
+
+// IncludeUnexported returns an Option that makes unexported struct
+// fields participate in the ordering.
+//
+// This is already the default: fields are read directly from memory
+// by offset, without going through reflect's exported-field checks.
+// IncludeUnexported exists so callers that depend on the behavior,
+// such as deterministic ordering of their own internal types, can
+// say so explicitly.
+//
+// Reading unexported fields bypasses the encapsulation of the types
+// that declare them. The values are only read, never written, but
+// callers should only use this with types they own or whose layout
+// they otherwise trust to contain what they expect.
+func IncludeUnexported() Option {
+	return func(c *config) { c.applied("IncludeUnexported") }
+}
+
+// TieBreak returns an Option that orders elements that are equal in
+// every other respect by less, which is given their indexes in the
+// slice passed to OfOpts. It runs only when all fields tie, making it
+// a simple way to order "then by insertion order" or "then by some
+// external priority" without writing a full comparison.
+//
+// Without TieBreak, such elements are equal. TieBreak only affects
+// OfOpts, since the indexes are those of its slice, and has no effect
+// with DerefStable, which breaks ties by pointer identity. With Desc,
+// the tie-breaker's order is reversed too.
+func TieBreak(less func(i, j int) bool) Option {
+	return func(c *config) {
+		c.tieBreak = less
+		c.applied("TieBreak")
+	}
+}
+
+// InvalidLast returns an Option that orders the elements for which
+// valid returns false after all others, as when floating records that
+// fail a checksum or validation to the bottom for triage. The valid
+// function is given indexes in the slice passed to OfOpts. Valid and
+// invalid elements are each ordered as usual among themselves, so two
+// invalid elements are only equal if they would be otherwise.
+//
+// Invalid elements order last even with Desc. Like TieBreak,
+// InvalidLast only affects OfOpts. The valid function is called twice
+// per comparison, so it should be cheap, or look up a precomputed
+// result.
+func InvalidLast(valid func(i int) bool) Option {
+	return func(c *config) {
+		c.valid = valid
+		c.applied("InvalidLast")
+	}
+}
+
+// Desc returns an Option that reverses the ordering, so that elements
+// sort from greatest to least. Equal elements are still equal.
+func Desc() Option {
+	return func(c *config) {
+		c.desc = true
+		c.applied("Desc")
+	}
+}
+
+// lessElem returns the less function for slice elements of type et,
+// as described by c. If two elements are equal, the result is that
+// of optEq, if non-nil.
+func (c *config) lessElem(et reflect.Type, optEq less) less {
+	return c.direct(c.forAddr(0, et, "", optEq))
+}
+
+// direct returns less, reversed if c has the Desc option.
+func (c *config) direct(less less) less {
+	if !c.desc {
+		return less
+	}
+	c.use("Desc")
+	return func(a, b unsafe.Pointer) bool { return less(b, a) }
+}
+
+// CompareBlankFields returns an Option that makes blank (_) struct
+// fields participate in the ordering like any other field, reading
+// them directly from memory.
+//
+// By default blank fields are skipped, so elements that differ only
+// in them are equal, including for FirstDuplicate, GroupBy and
+// SortUnique. Blank fields usually hold padding or reserved space,
+// but code that stores data in them can use this option to have that
+// data matter.
+func CompareBlankFields() Option {
+	return func(c *config) {
+		c.compareBlank = true
+		c.applied("CompareBlankFields")
+	}
+}
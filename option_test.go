@@ -0,0 +1,185 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"unsafe"
+)
+
+type unexportedFields struct {
+	name string
+	n    int8
+	f    float64
+}
+
+func TestIncludeUnexported(t *testing.T) {
+	in := []unexportedFields{
+		{"b", 1, 2},
+		{"a", 2, 1},
+		{"a", 1, 3},
+		{"a", 1, 2},
+	}
+	want := []unexportedFields{
+		{"a", 1, 2},
+		{"a", 1, 3},
+		{"a", 2, 1},
+		{"b", 1, 2},
+	}
+	for _, opts := range [][]Option{nil, {IncludeUnexported()}} {
+		got := append([]unexportedFields(nil), in...)
+		sort.Slice(got, OfOpts(got, opts...))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("with %d opts:\n got: %v\nwant: %v", len(opts), got, want)
+		}
+	}
+}
+
+func TestValidateOpts(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       reflect.Type
+		opts    []Option
+		wantErr string
+	}{
+		{"none", reflect.TypeOf(TStringInt{}), nil, ""},
+		{"float_ok", reflect.TypeOf(measurement{}), []Option{FloatEpsilon(0.1)}, ""},
+		{"float_nested_ok", reflect.TypeOf([2]float32{}), []Option{FloatEpsilon(0.1)}, ""},
+		{"float_unused", reflect.TypeOf(TStringInt{}), []Option{FloatEpsilon(0.1)},
+			"lesser: option FloatEpsilon does not apply to type lesser.TStringInt"},
+		{"unexported_ok", reflect.TypeOf(unexportedFields{}), []Option{IncludeUnexported()}, ""},
+		{"some_unused", reflect.TypeOf(0), []Option{IncludeUnexported(), SampledBytes(4)},
+			"lesser: options IncludeUnexported, SampledBytes do not apply to type int"},
+		{"bytes_ok", reflect.TypeOf(blobRecord{}), []Option{SampledBytes(4)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOpts(tt.t, tt.opts...)
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("got error %q; want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTieBreak(t *testing.T) {
+	in := []blankStruct{{2, 0, 0}, {1, 9, 1}, {1, 5, 1}, {0, 0, 0}, {1, 7, 1}}
+	rank := []int{0, 3, 1, 0, 2}
+	less := OfOpts(in, TieBreak(func(i, j int) bool { return rank[i] < rank[j] }))
+	got := sortedIndices(len(in), less)
+	if want := []int{3, 2, 4, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	empty := make([]struct{}, 3)
+	less = OfOpts(empty, TieBreak(func(i, j int) bool { return i > j }))
+	if !less(2, 1) || less(1, 2) {
+		t.Error("TieBreak not used for zero-size elements")
+	}
+}
+
+func TestInvalidLast(t *testing.T) {
+	in := []TStringInt{{"c", 0}, {"a", 1}, {"b", 0}, {"a", 0}, {"d", 0}}
+	bad := map[TStringInt]bool{{"a", 1}: true, {"d", 0}: true}
+	valid := func(i int) bool { return !bad[in[i]] }
+	got := sortedIndices(len(in), OfOpts(in, InvalidLast(valid)))
+	if want := []int{3, 2, 0, 1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	got = sortedIndices(len(in), OfOpts(in, InvalidLast(valid), Desc()))
+	if want := []int{0, 2, 3, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with Desc: got %v; want %v", got, want)
+	}
+}
+
+func TestDesc(t *testing.T) {
+	in := []TStringInt{{"a", 1}, {"b", 0}, {"a", 2}}
+	sort.Slice(in, OfOpts(in, Desc()))
+	if want := []TStringInt{{"b", 0}, {"a", 2}, {"a", 1}}; !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+	if err := ValidateOpts(reflect.TypeOf(0), Desc()); err != nil {
+		t.Errorf("ValidateOpts: %v", err)
+	}
+}
+
+func TestOfReverse(t *testing.T) {
+	nan := math.NaN()
+	tests := []interface{}{
+		[]int{3, 1, 2, 3, -5},
+		[]float64{2, nan, -1, 0, nan, 2},
+		[]complex128{complex(1, 2), complex(1, nan), complex(0, 5), complex(1, -1)},
+		[]TStringInt{{"a", 1}, {"b", 0}, {"a", 2}, {"b", 0}},
+	}
+	for _, in := range tests {
+		rv := reflect.ValueOf(in)
+		asc := reflect.New(rv.Type()).Elem()
+		asc.Set(reflect.AppendSlice(asc, rv))
+		sort.SliceStable(asc.Interface(), Of(asc.Interface()))
+		desc := reflect.New(rv.Type()).Elem()
+		desc.Set(reflect.AppendSlice(desc, rv))
+		sort.SliceStable(desc.Interface(), OfReverse(desc.Interface()))
+
+		var want []string
+		for i := asc.Len() - 1; i >= 0; i-- {
+			want = append(want, fmt.Sprint(asc.Index(i)))
+		}
+		var got []string
+		for i := 0; i < desc.Len(); i++ {
+			got = append(got, fmt.Sprint(desc.Index(i)))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%T: got %v; want %v", in, got, want)
+		}
+		less := OfReverse(in)
+		for i := 0; i < rv.Len(); i++ {
+			if less(i, i) {
+				t.Errorf("%T: element %d orders before itself", in, i)
+			}
+		}
+	}
+}
+
+func TestCompareBlankFields(t *testing.T) {
+	// Composite literals don't store values for blank fields, so
+	// write them directly.
+	in := []blankStruct{{1, 0, 3}, {1, 0, 3}, {0, 0, 9}, {1, 0, 3}}
+	for i, v := range []int{5, 2, 9, 4} {
+		setBlank(&in[i], v)
+	}
+	less := OfOpts(in)
+	if less(0, 1) || less(1, 0) {
+		t.Error("by default, elements differing only in a blank field should be equal")
+	}
+
+	less = OfOpts(in, CompareBlankFields())
+	if less(0, 1) || !less(1, 0) {
+		t.Error("with CompareBlankFields, blank 2 should order before blank 5")
+	}
+	got := sortedIndices(len(in), less)
+	if want := []int{2, 1, 3, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with CompareBlankFields: got %v; want %v", got, want)
+	}
+
+	if err := ValidateOpts(reflect.TypeOf(blankStruct{}), CompareBlankFields()); err != nil {
+		t.Errorf("ValidateOpts: %v", err)
+	}
+	if err := ValidateOpts(reflect.TypeOf(TStringInt{}), CompareBlankFields()); err == nil {
+		t.Error("ValidateOpts: want error for type without blank fields")
+	}
+}
+
+// setBlank sets the blank field of b to v.
+func setBlank(b *blankStruct, v int) {
+	*(*int)(unsafe.Pointer(uintptr(unsafe.Pointer(b)) + reflect.TypeOf(*b).Field(1).Offset)) = v
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// cmpFunc is a three-way comparison of two values of the same type,
+// given their addresses. It returns a negative number, zero or a
+// positive number if *a orders before, the same as or after *b.
+type cmpFunc func(a, b unsafe.Pointer) int
+
+var (
+	registryMu  sync.RWMutex
+	registry    = map[reflect.Type]cmpFunc{}
+	registryGen int // incremented by each register
+)
+
+// register makes values of type t order by cmp, overriding any other
+// ordering rules for t.
+func register(t reflect.Type, cmp cmpFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[t] = cmp
+	registryGen++
+}
+
+// registryGeneration returns the number of registrations so far.
+// Orderings built before a registration may be out of date.
+func registryGeneration() int {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registryGen
+}
+
+// registered returns the comparison registered for t, or nil.
+func registered(t reflect.Type) cmpFunc {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[t]
+}
+
+// A typeRule is an ordering for one type installed by an Option. It
+// takes precedence over the package registry.
+type typeRule struct {
+	name string // option name, for ValidateOpts
+	cmp  cmpFunc
+}
+
+// setType installs rule as the ordering for type t. The args are those
+// of the option, as for config.applied.
+func (c *config) setType(t reflect.Type, rule typeRule, args ...interface{}) {
+	if c.types == nil {
+		c.types = make(map[reflect.Type]typeRule)
+	}
+	c.types[t] = rule
+	c.applied(rule.name, args...)
+}
+
+func lessCmp(cmp cmpFunc) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			c := cmp(at(a, off), at(b, off))
+			if c == 0 {
+				if optEq != nil {
+					return optEq(a, b)
+				}
+				return false
+			}
+			return c < 0
+		}
+	}
+}
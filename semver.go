@@ -0,0 +1,154 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// Semver returns an Option that orders the string field at path as a
+// semantic version (https://semver.org), such as "v1.10.0" or
+// "1.0.0-rc.1+build.5", so that "v1.9.0" orders before "v1.10.0" and
+// a prerelease before its release.
+//
+// The leading "v" is optional, and "v1" and "v1.2" are shorthands for
+// "v1.0.0" and "v1.2.0", as with golang.org/x/mod/semver. Prerelease
+// identifiers are compared as the specification says: numeric ones by
+// value and before alphanumeric ones, which compare as strings, with
+// a prefix of a longer list of identifiers ordering first. Build
+// metadata doesn't affect precedence.
+//
+// Strings that aren't valid versions order before all valid ones.
+// Strings of equal precedence, such as "1.0.0+a", "v1.0.0" and "v1",
+// and invalid strings are then ordered by their raw values.
+func Semver(path string) Option {
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("Semver(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				if t.Kind() != reflect.String {
+					return nil
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					if c := cmpSemver(*(*string)(at(a, off)), *(*string)(at(b, off))); c != 0 {
+						return c < 0
+					}
+					return next(a, b)
+				}
+			},
+		})
+	}
+}
+
+// semver is a parsed semantic version. The numbers are decimal
+// strings without leading zeros, and compared with cmpSegment.
+type semver struct {
+	major, minor, patch string
+	pre                 []string // prerelease identifiers, if any
+}
+
+// cmpSemver compares a and b as described by Semver, returning -1, 0
+// or +1.
+func cmpSemver(a, b string) int {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	switch {
+	case !okA || !okB:
+		if okA == okB {
+			return 0
+		}
+		if okB {
+			return -1
+		}
+		return 1
+	}
+	if c := cmpSegment(va.major, vb.major); c != 0 {
+		return sign(c)
+	}
+	if c := cmpSegment(va.minor, vb.minor); c != 0 {
+		return sign(c)
+	}
+	if c := cmpSegment(va.patch, vb.patch); c != 0 {
+		return sign(c)
+	}
+	// A version without a prerelease orders after those with one.
+	if (va.pre == nil) != (vb.pre == nil) {
+		if va.pre == nil {
+			return 1
+		}
+		return -1
+	}
+	for i := 0; i < len(va.pre) && i < len(vb.pre); i++ {
+		if c := cmpSegment(va.pre[i], vb.pre[i]); c != 0 {
+			return sign(c)
+		}
+	}
+	return sign(len(va.pre) - len(vb.pre))
+}
+
+// parseSemver parses s as described by Semver.
+func parseSemver(s string) (v semver, ok bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		if !validIdents(s[i+1:], false) {
+			return v, false
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre := s[i+1:]
+		if !validIdents(pre, true) {
+			return v, false
+		}
+		v.pre = strings.Split(pre, ".")
+		s = s[:i]
+	}
+	nums := strings.Split(s, ".")
+	if len(nums) > 3 || len(nums) < 3 && v.pre != nil {
+		return v, false
+	}
+	for _, n := range nums {
+		if !isDigits(n) || len(n) > 1 && n[0] == '0' {
+			return v, false
+		}
+	}
+	nums = append(nums, "0", "0")
+	v.major, v.minor, v.patch = nums[0], nums[1], nums[2]
+	return v, true
+}
+
+// validIdents reports whether s is a non-empty list of dot-separated
+// identifiers of ASCII letters, digits and hyphens. With noZeros,
+// numeric identifiers mustn't have leading zeros, as for prereleases.
+func validIdents(s string, noZeros bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for i := 0; i < len(id); i++ {
+			if c := id[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+		if noZeros && len(id) > 1 && id[0] == '0' && isDigits(id) {
+			return false
+		}
+	}
+	return true
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSemver(t *testing.T) {
+	type release struct {
+		Version string
+		Notes   string
+	}
+	// The example ordering from the specification, and then some.
+	want := []string{
+		"",
+		"1.01.0",
+		"bogus",
+		"v1.0.0-alpha..1",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.0+build.5",
+		"v1",
+		"v1.0.0",
+		"v1.2",
+		"v1.9.0",
+		"v1.10.0",
+		"v10.0.0",
+		"v99999999999999999999.0.0",
+	}
+	in := make([]release, len(want))
+	for i, j := range []int{5, 19, 13, 0, 7, 2, 16, 11, 3, 18, 9, 1, 14, 6, 17, 12, 4, 10, 8, 15} {
+		in[i].Version = want[j]
+	}
+	sort.Slice(in, OfOpts(in, Semver("Version")))
+	var got []string
+	for _, r := range in {
+		got = append(got, r.Version)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		s  string
+		ok bool
+	}{
+		{"v1.2.3", true},
+		{"1.2.3-0.a-b.c+x.01", true},
+		{"v1.2", true},
+		{"v1", true},
+		{"v1.2-pre", false}, // shorthands can't have prereleases
+		{"v1.2.3.4", false},
+		{"v01.2.3", false},
+		{"v1.2.3-01", false},
+		{"v1.2.3-", false},
+		{"v1.2.3+", false},
+		{"v1.2.3-a_b", false},
+		{"v", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseSemver(tt.s); ok != tt.ok {
+			t.Errorf("parseSemver(%q) ok = %v; want %v", tt.s, ok, tt.ok)
+		}
+	}
+}
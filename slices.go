@@ -0,0 +1,200 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// SliceByMin returns an Option that orders slices by their smallest
+// element, as ordered by Of for the element type, and then by length.
+// Empty slices order first. For example, [][]int{{5, 2}, {3}, {}, {2}}
+// orders as {}, {2}, {5, 2}, {3}.
+//
+// Slices with equal minimums and lengths are equal, even if their
+// other elements differ. The minimum is found on each comparison, so
+// comparing two slices costs time proportional to their lengths.
+//
+// SliceByMin doesn't apply to byte slices ordered by SampledBytes.
+func SliceByMin() Option {
+	return func(c *config) {
+		c.sliceBy = "SliceByMin"
+		c.applied("SliceByMin")
+	}
+}
+
+// SliceByMax is like SliceByMin, but orders slices by their largest
+// element.
+func SliceByMax() Option {
+	return func(c *config) {
+		c.sliceBy = "SliceByMax"
+		c.applied("SliceByMax")
+	}
+}
+
+// sliceHeader is the memory layout of a slice.
+type sliceHeader struct {
+	data     unsafe.Pointer
+	len, cap int
+}
+
+// lessSlice returns the leaf for the slice type t, comparing slices
+// lexicographically: element by element, by the ordering of their
+// element type, with a prefix of a longer slice ordering first.
+//
+// The element ordering is built once. For recursive types, such as
+// a struct with a field of a slice of itself, it's shared with the
+// inner slices, as described by elemLess.
+func (c *config) lessSlice(t reflect.Type, path string) func(off uintptr, optEq less) less {
+	size := t.Elem().Size()
+	elemLess := c.elemLess(t, func() less { return c.forAddr(0, t.Elem(), path, nil) })
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			sa, sb := (*sliceHeader)(at(a, off)), (*sliceHeader)(at(b, off))
+			n := sa.len
+			if sb.len < n {
+				n = sb.len
+			}
+			if sa.data != sb.data {
+				less := *elemLess
+				for i := 0; i < n; i++ {
+					ea, eb := elem(sa.data, size, i), elem(sb.data, size, i)
+					if less(ea, eb) {
+						return true
+					}
+					if less(eb, ea) {
+						return false
+					}
+				}
+			}
+			if sa.len != sb.len {
+				return sa.len < sb.len
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// elemLess returns a cell holding the ordering of the elements of
+// the slice or pointer type t, built by build. If t's ordering is
+// already being built, further up the same type walk, it returns that
+// ordering's cell instead, which will be filled in by the time it's
+// used.
+func (c *config) elemLess(t reflect.Type, build func() less) *less {
+	if cell, ok := c.building[t]; ok {
+		if g := c.derefGuard; g != nil && !g.recursive {
+			g.recursive = true
+		}
+		return cell
+	}
+	if c.building == nil {
+		c.building = make(map[reflect.Type]*less)
+	}
+	cell := new(less)
+	c.building[t] = cell
+	*cell = build()
+	delete(c.building, t)
+	return cell
+}
+
+// lessSliceBy returns the leaf for the slice type t under the
+// SliceByMin or SliceByMax option in c.
+func (c *config) lessSliceBy(t reflect.Type, path string) func(off uintptr, optEq less) less {
+	c.use(c.sliceBy)
+	useMax := c.sliceBy == "SliceByMax"
+	size := t.Elem().Size()
+	elemLess := c.forAddr(0, t.Elem(), path, nil)
+
+	// extreme returns the address of the first smallest (or
+	// largest) element of s.
+	extreme := func(s *sliceHeader) unsafe.Pointer {
+		best := s.data
+		for i := 1; i < s.len; i++ {
+			e := elem(s.data, size, i)
+			if useMax && elemLess(best, e) || !useMax && elemLess(e, best) {
+				best = e
+			}
+		}
+		return best
+	}
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			sa, sb := (*sliceHeader)(at(a, off)), (*sliceHeader)(at(b, off))
+			if sa.len == 0 || sb.len == 0 {
+				if sa.len != sb.len {
+					return sa.len == 0
+				}
+			} else {
+				ea, eb := extreme(sa), extreme(sb)
+				if elemLess(ea, eb) {
+					return true
+				}
+				if elemLess(eb, ea) {
+					return false
+				}
+				if sa.len != sb.len {
+					return sa.len < sb.len
+				}
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// ByLength returns an Option that orders the slice, map, string or
+// array field at path by its length first. Fields of equal length are
+// then ordered by their contents, for slices, strings and arrays, or
+// by the fields that follow, for maps, whose contents have no default
+// order (but see DeepMaps).
+//
+// Slice and string lengths are read from their headers, without
+// touching their contents. A string's length is in bytes, as for
+// len. Since all arrays of a type have the same length, ByLength on
+// an array field just orders by its contents.
+func ByLength(path string) Option {
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("ByLength(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				var length func(p unsafe.Pointer) int
+				next := optEq
+				switch t.Kind() {
+				case reflect.Slice:
+					length = func(p unsafe.Pointer) int { return (*sliceHeader)(p).len }
+					next = c.forType(off, t, path, optEq)
+				case reflect.Map:
+					length = func(p unsafe.Pointer) int { return reflect.NewAt(t, p).Elem().Len() }
+					if c.deepMaps {
+						next = c.forType(off, t, path, optEq)
+					}
+				case reflect.String:
+					length = func(p unsafe.Pointer) int { return len(*(*string)(p)) }
+					next = c.forType(off, t, path, optEq)
+				case reflect.Array:
+					return c.forType(off, t, path, optEq)
+				default:
+					return nil
+				}
+				return func(a, b unsafe.Pointer) bool {
+					if la, lb := length(at(a, off)), length(at(b, off)); la != lb {
+						return la < lb
+					}
+					if next != nil {
+						return next(a, b)
+					}
+					return false
+				}
+			},
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type series struct {
+	Samples []float64
+	Name    string
+}
+
+func TestSliceByMinMax(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+		in   [][]int
+		want [][]int
+	}{
+		{"min", SliceByMin(),
+			[][]int{{5, 2}, {3}, {}, {2}, {9, 2, 2}},
+			[][]int{{}, {2}, {5, 2}, {9, 2, 2}, {3}}},
+		{"max", SliceByMax(),
+			[][]int{{5, 2}, {3}, {}, {9}, {1, 5}},
+			[][]int{{}, {3}, {5, 2}, {1, 5}, {9}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort.SliceStable(tt.in, OfOpts(tt.in, tt.opt))
+			if !reflect.DeepEqual(tt.in, tt.want) {
+				t.Errorf("got %v; want %v", tt.in, tt.want)
+			}
+		})
+	}
+}
+
+func TestSliceByMinEmptyElems(t *testing.T) {
+	// Elements with nothing to compare are all equal, so only
+	// emptiness and length matter.
+	in := [][]struct{}{{{}, {}}, {}, {{}}}
+	for _, opt := range []Option{SliceByMin(), SliceByMax()} {
+		sort.Slice(in, OfOpts(in, opt))
+		if len(in[0]) != 0 || len(in[1]) != 1 || len(in[2]) != 2 {
+			t.Errorf("got lengths %d, %d, %d; want 0, 1, 2", len(in[0]), len(in[1]), len(in[2]))
+		}
+	}
+}
+
+func TestSliceByMinField(t *testing.T) {
+	in := []series{
+		{[]float64{3, 1}, "b"},
+		{nil, "z"},
+		{[]float64{1, 4}, "a"},
+		{[]float64{0.5, 7, 2}, "c"},
+	}
+	sort.Slice(in, OfOpts(in, SliceByMin()))
+	var got []string
+	for _, s := range in {
+		got = append(got, s.Name)
+	}
+	if want := []string{"z", "c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if err := ValidateOpts(reflect.TypeOf(series{}), SliceByMax()); err != nil {
+		t.Errorf("ValidateOpts: %v", err)
+	}
+}
+
+func TestByLength(t *testing.T) {
+	t.Run("slice", func(t *testing.T) {
+		type post struct {
+			Tags []string
+			Name string
+		}
+		in := []post{{[]string{"a", "b"}, "w"}, {nil, "x"}, {[]string{"z"}, "z"}, {[]string{"y"}, "y"}}
+		sort.Slice(in, OfOpts(in, ByLength("Tags")))
+		want := []post{{nil, "x"}, {[]string{"y"}, "y"}, {[]string{"z"}, "z"}, {[]string{"a", "b"}, "w"}}
+		if !reflect.DeepEqual(in, want) {
+			t.Errorf("got %v; want %v", in, want)
+		}
+	})
+	t.Run("map", func(t *testing.T) {
+		type host struct {
+			Attrs map[string]int
+			Name  string
+		}
+		in := []host{{map[string]int{"a": 1, "b": 2}, "w"}, {nil, "y"}, {map[string]int{"z": 0}, "z"}, {map[string]int{}, "x"}}
+		sort.Slice(in, OfOpts(in, ByLength("Attrs")))
+		want := []host{{map[string]int{}, "x"}, {nil, "y"}, {map[string]int{"z": 0}, "z"}, {map[string]int{"a": 1, "b": 2}, "w"}}
+		if !reflect.DeepEqual(in, want) {
+			t.Errorf("got %v; want %v", in, want)
+		}
+	})
+	t.Run("string", func(t *testing.T) {
+		in := []contact{{"bb"}, {"ccc"}, {""}, {"ab"}, {"z"}}
+		sort.Slice(in, OfOpts(in, ByLength("Email")))
+		want := []contact{{""}, {"z"}, {"ab"}, {"bb"}, {"ccc"}}
+		if !reflect.DeepEqual(in, want) {
+			t.Errorf("got %v; want %v", in, want)
+		}
+	})
+	t.Run("array", func(t *testing.T) {
+		type code struct {
+			Digits [2]int
+			Name   string
+		}
+		in := []code{{[2]int{1, 2}, "a"}, {[2]int{0, 9}, "c"}, {[2]int{0, 9}, "b"}}
+		sort.Slice(in, OfOpts(in, ByLength("Digits")))
+		want := []code{{[2]int{0, 9}, "b"}, {[2]int{0, 9}, "c"}, {[2]int{1, 2}, "a"}}
+		if !reflect.DeepEqual(in, want) {
+			t.Errorf("got %v; want %v", in, want)
+		}
+	})
+	t.Run("float", func(t *testing.T) {
+		if err := ValidateOpts(reflect.TypeOf(measurement{}), ByLength("V")); err == nil {
+			t.Error("ByLength on a float field: got nil error")
+		}
+	})
+}
+
+func TestSliceLexicographic(t *testing.T) {
+	if !Before([][]int{{1}, nil}, 1, 0) || Before([][]int{{}, nil}, 1, 0) || Before([][]int{{}, nil}, 0, 1) {
+		t.Error("want nil equal to empty, and before non-empty")
+	}
+
+	ints := [][]int{{1, 2, 3}, {1, 2}, {0, 9}, nil, {1, 3}, {1, 2, 3}}
+	sort.Slice(ints, Of(ints))
+	if want := [][]int{nil, {0, 9}, {1, 2}, {1, 2, 3}, {1, 2, 3}, {1, 3}}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("[][]int: got %v; want %v", ints, want)
+	}
+
+	strs := [][]string{{"b"}, {"a", "z"}, {"a"}, {}, {"a", "b"}}
+	sort.Slice(strs, Of(strs))
+	if want := [][]string{{}, {"a"}, {"a", "b"}, {"a", "z"}, {"b"}}; !reflect.DeepEqual(strs, want) {
+		t.Errorf("[][]string: got %q; want %q", strs, want)
+	}
+
+	nested := [][][]int{{{2}}, {{1, 5}, {0}}, {{1, 5}}, {}}
+	sort.Slice(nested, Of(nested))
+	if want := [][][]int{{}, {{1, 5}}, {{1, 5}, {0}}, {{2}}}; !reflect.DeepEqual(nested, want) {
+		t.Errorf("[][][]int: got %v; want %v", nested, want)
+	}
+
+	nan := math.NaN()
+	floats := [][]float64{{1, 2}, {nan, 3}, {nan, 1}}
+	sort.Slice(floats, Of(floats))
+	if floats[0][1] != 1 || floats[1][1] != 3 || floats[2][0] != 1 {
+		t.Errorf("[][]float64: got %v; want [[NaN 1] [NaN 3] [1 2]]", floats)
+	}
+}
+
+func TestSliceField(t *testing.T) {
+	in := []series{
+		{[]float64{2}, "b"},
+		{[]float64{1, 5}, "c"},
+		{nil, "z"},
+		{[]float64{2}, "a"},
+		{[]float64{1}, "d"},
+	}
+	sort.Slice(in, Of(in))
+	var got []string
+	for _, s := range in {
+		got = append(got, s.Name)
+	}
+	if want := []string{"z", "d", "c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestSliceOfSlicesField(t *testing.T) {
+	type table struct {
+		Rows [][]string
+		IDs  []int
+	}
+	in := []table{
+		{[][]string{{"a", "b"}}, []int{2}},
+		{[][]string{{"a"}, {"z"}}, []int{1}},
+		{[][]string{{"a", "b"}}, []int{1, 0}},
+		{nil, []int{5}},
+		{[][]string{{"a"}}, nil},
+	}
+	sort.Slice(in, Of(in))
+	want := []table{
+		{nil, []int{5}},
+		{[][]string{{"a"}}, nil},
+		{[][]string{{"a"}, {"z"}}, []int{1}},
+		{[][]string{{"a", "b"}}, []int{1, 0}},
+		{[][]string{{"a", "b"}}, []int{2}},
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v\nwant %v", in, want)
+	}
+}
+
+type treeNode struct {
+	Label string
+	Kids  []treeNode
+}
+
+func TestSliceRecursive(t *testing.T) {
+	leaf := func(s string) treeNode { return treeNode{Label: s} }
+	in := []treeNode{
+		{"a", []treeNode{leaf("y")}},
+		{"a", []treeNode{{"x", []treeNode{leaf("q")}}}},
+		{"a", nil},
+		{"a", []treeNode{leaf("x")}},
+	}
+	sort.Slice(in, Of(in))
+	want := []treeNode{
+		{"a", nil},
+		{"a", []treeNode{leaf("x")}},
+		{"a", []treeNode{{"x", []treeNode{leaf("q")}}}},
+		{"a", []treeNode{leaf("y")}},
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %+v\nwant %+v", in, want)
+	}
+}
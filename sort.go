@@ -0,0 +1,186 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// SortSlice sorts slice in place, using the ordering of Of.
+//
+// Slices of bool kind without a custom ordering are sorted in linear
+// time by counting their false values. For integer enums, see
+// SortEnum.
+//
+// The slice argument must be a slice.
+func SortSlice(slice interface{}) {
+	if rv := reflect.ValueOf(slice); rv.Kind() == reflect.Slice {
+		if et := rv.Type().Elem(); et.Kind() == reflect.Bool && hasDefaultOrder(et) {
+			sortBools(rv)
+			return
+		}
+	}
+	sort.Slice(slice, Of(slice))
+}
+
+// Interface returns a sort.Interface for slice that orders its
+// elements by Of, for use with sort.Sort, sort.Stable, sort.IsSorted
+// and other functions that take one. Swap moves whole elements, with
+// reflect.Swapper.
+//
+// Like the function returned by Of, the result is only valid for
+// slice's current backing array and length.
+//
+// The slice argument must be a slice.
+func Interface(slice interface{}) sort.Interface {
+	return &keySorter{
+		n:    reflect.ValueOf(slice).Len(),
+		less: Of(slice),
+		swap: reflect.Swapper(slice),
+	}
+}
+
+// SortStrings sorts ss in place, using the ordering of OfOpts with
+// opts, such as Fold or StringIgnoring. With no options, it's
+// sort.Strings.
+func SortStrings(ss []string, opts ...Option) {
+	if len(opts) == 0 {
+		sort.Strings(ss)
+		return
+	}
+	sort.Slice(ss, OfOpts(ss, opts...))
+}
+
+// SortInts sorts s in place, using the ordering of OfOpts with opts.
+// With no options, it's sort.Ints.
+func SortInts(s []int, opts ...Option) {
+	if len(opts) == 0 {
+		sort.Ints(s)
+		return
+	}
+	sort.Slice(s, OfOpts(s, opts...))
+}
+
+// SortFloat64s sorts s in place, using the ordering of OfOpts with
+// opts, such as FloatEpsilon. With no options, it's sort.Float64s,
+// which, like Of, orders NaNs first.
+func SortFloat64s(s []float64, opts ...Option) {
+	if len(opts) == 0 {
+		sort.Float64s(s)
+		return
+	}
+	sort.Slice(s, OfOpts(s, opts...))
+}
+
+// SortedKeys returns the keys of the map m as a slice of m's key
+// type, sorted using the ordering of Of for that type. Struct and
+// array keys are ordered field by field and element by element, like
+// any other slice element.
+//
+// SortedKeys panics if m is not a map.
+func SortedKeys(m interface{}) interface{} {
+	mv := reflect.ValueOf(m)
+	if mv.Kind() != reflect.Map {
+		panic(fmt.Sprintf("lesser: SortedKeys argument of type %T is not a map", m))
+	}
+	keys := reflect.Append(reflect.MakeSlice(reflect.SliceOf(mv.Type().Key()), 0, mv.Len()), mv.MapKeys()...)
+	ks := keys.Interface()
+	sort.Slice(ks, OfValue(keys))
+	return ks
+}
+
+// StableSort sorts slice in place, using the ordering of Of, keeping
+// equal elements in their input order. Use it when equal elements
+// must keep input order, for example when they differ only in blank
+// (_) fields or in values like NaN payloads that Of treats as equal.
+//
+// If equal elements of slice's type are always identical, stability
+// can't be observed, and StableSort uses the cheaper unstable sort.
+//
+// The slice argument must be a slice.
+func StableSort(slice interface{}) {
+	if indistinguishable(reflect.TypeOf(slice).Elem()) {
+		SortSlice(slice)
+		return
+	}
+	sort.SliceStable(slice, Of(slice))
+}
+
+// indistinguishable reports whether any two values of type t that
+// are equal under Of's default ordering are also identical: every
+// bit of the value participates in the comparison, and no two
+// distinct values tie.
+//
+// Floats aren't, because 0 and -0 are equal, as are all NaNs. Nor are
+// interfaces, types with custom comparisons, or structs with blank
+// or skipped fields.
+func indistinguishable(t reflect.Type) bool {
+	if !hasDefaultOrder(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.UnsafePointer:
+		return true
+	case reflect.Array:
+		return indistinguishable(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.Name == "_" || sf.Tag.Get("lesser") == "-" || !indistinguishable(sf.Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// SortChunks sorts each consecutive run of chunkSize elements of
+// slice in place, using the ordering of Of. The final chunk may be
+// shorter.
+//
+// It returns the chunk boundaries as [start, end) index pairs, in
+// order, so the sorted runs can later be merged by the caller.
+//
+// The slice argument must be a slice. SortChunks panics if chunkSize
+// is not positive.
+func SortChunks(slice interface{}, chunkSize int) (chunks [][]int) {
+	if chunkSize <= 0 {
+		panic("lesser: SortChunks chunk size must be positive")
+	}
+	rv := reflect.ValueOf(slice)
+	n := rv.Len()
+	if n == 0 {
+		return nil
+	}
+	cs := &chunkSorter{less: Of(slice), swap: reflect.Swapper(slice)}
+	for start := 0; start < n; start += chunkSize {
+		end := start + chunkSize
+		if end > n {
+			end = n
+		}
+		cs.lo, cs.n = start, end-start
+		sort.Sort(cs)
+		chunks = append(chunks, []int{start, end})
+	}
+	return chunks
+}
+
+// chunkSorter is a sort.Interface for the n elements starting at
+// index lo of a slice.
+type chunkSorter struct {
+	lo, n int
+	less  func(i, j int) bool
+	swap  func(i, j int)
+}
+
+func (s *chunkSorter) Len() int           { return s.n }
+func (s *chunkSorter) Less(i, j int) bool { return s.less(s.lo+i, s.lo+j) }
+func (s *chunkSorter) Swap(i, j int)      { s.swap(s.lo+i, s.lo+j) }
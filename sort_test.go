@@ -0,0 +1,174 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSortChunks(t *testing.T) {
+	s := []int{5, 3, 9, 1, 8, 2, 7, 6}
+	chunks := SortChunks(s, 3)
+	wantChunks := [][]int{{0, 3}, {3, 6}, {6, 8}}
+	if !reflect.DeepEqual(chunks, wantChunks) {
+		t.Errorf("chunks = %v; want %v", chunks, wantChunks)
+	}
+	want := []int{3, 5, 9, 1, 2, 8, 6, 7}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("slice = %v; want %v", s, want)
+	}
+
+	if got := SortChunks([]int{}, 3); got != nil {
+		t.Errorf("empty: got %v; want nil", got)
+	}
+
+	structs := []TStringInt{{"b", 1}, {"a", 1}, {"c", 0}}
+	SortChunks(structs, 10)
+	wantStructs := []TStringInt{{"a", 1}, {"b", 1}, {"c", 0}}
+	if !reflect.DeepEqual(structs, wantStructs) {
+		t.Errorf("structs = %v; want %v", structs, wantStructs)
+	}
+}
+
+func TestInterface(t *testing.T) {
+	type rec struct {
+		Name string
+		P    *int
+		Pad  [5]int64
+		N    int
+	}
+	x := new(int)
+	in := []rec{{"b", x, [5]int64{1}, 2}, {"a", nil, [5]int64{2}, 1}, {"b", x, [5]int64{1}, 1}, {"a", nil, [5]int64{2}, 0}}
+	want := append([]rec(nil), in...)
+	sort.SliceStable(want, Of(want))
+
+	for _, tt := range []struct {
+		name string
+		sort func(sort.Interface)
+	}{{"Sort", sort.Sort}, {"Stable", sort.Stable}} {
+		got := append([]rec(nil), in...)
+		tt.sort(Interface(got))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v; want %v", tt.name, got, want)
+		}
+		if !sort.IsSorted(Interface(got)) {
+			t.Errorf("%s: IsSorted = false", tt.name)
+		}
+	}
+
+	empty := Interface([]rec{})
+	if empty.Len() != 0 {
+		t.Errorf("Len of empty = %d", empty.Len())
+	}
+	sort.Sort(empty)
+}
+
+func TestSortTyped(t *testing.T) {
+	ss := []string{"banana", "Apple", "cherry"}
+	SortStrings(ss)
+	if want := []string{"Apple", "banana", "cherry"}; !reflect.DeepEqual(ss, want) {
+		t.Errorf("SortStrings = %q; want %q", ss, want)
+	}
+	SortStrings(ss, Fold(), Desc())
+	if want := []string{"cherry", "banana", "Apple"}; !reflect.DeepEqual(ss, want) {
+		t.Errorf("SortStrings with Fold, Desc = %q; want %q", ss, want)
+	}
+
+	is := []int{3, -1, 2}
+	SortInts(is)
+	if want := []int{-1, 2, 3}; !reflect.DeepEqual(is, want) {
+		t.Errorf("SortInts = %v; want %v", is, want)
+	}
+	SortInts(is, Desc())
+	if want := []int{3, 2, -1}; !reflect.DeepEqual(is, want) {
+		t.Errorf("SortInts with Desc = %v; want %v", is, want)
+	}
+
+	fs := []float64{2, math.NaN(), -1}
+	SortFloat64s(fs)
+	if !math.IsNaN(fs[0]) || fs[1] != -1 || fs[2] != 2 {
+		t.Errorf("SortFloat64s = %v; want [NaN -1 2]", fs)
+	}
+	fs = []float64{1.02, 0.5, 1.01}
+	SortFloat64s(fs, FloatEpsilon(0.1))
+	if fs[0] != 0.5 {
+		t.Errorf("SortFloat64s with FloatEpsilon = %v; want 0.5 first", fs)
+	}
+}
+
+func TestStableSort(t *testing.T) {
+	blanks := []blankStruct{{2, 0, 0}, {1, 9, 1}, {1, 5, 1}, {0, 0, 0}, {1, 7, 1}}
+	StableSort(blanks)
+	wantBlanks := []blankStruct{{0, 0, 0}, {1, 9, 1}, {1, 5, 1}, {1, 7, 1}, {2, 0, 0}}
+	if !reflect.DeepEqual(blanks, wantBlanks) {
+		t.Errorf("blanks = %v; want %v", blanks, wantBlanks)
+	}
+
+	// Takes the unstable path; the result is the same either way.
+	type single struct{ N int }
+	singles := []single{{3}, {1}, {2}, {1}, {0}}
+	StableSort(singles)
+	wantSingles := []single{{0}, {1}, {1}, {2}, {3}}
+	if !reflect.DeepEqual(singles, wantSingles) {
+		t.Errorf("singles = %v; want %v", singles, wantSingles)
+	}
+}
+
+func TestIndistinguishable(t *testing.T) {
+	type single struct{ N int }
+	type nested struct {
+		S single
+		A [2]string
+		P *int
+	}
+	tests := []struct {
+		v    interface{}
+		want bool
+	}{
+		{0, true},
+		{"", true},
+		{single{}, true},
+		{nested{}, true},
+		{[3]uint8{}, true},
+		{0.0, false},
+		{measurement{}, false},
+		{blankStruct{}, false},
+		{time.Time{}, false},
+		{revInt{}, false},
+		{struct{ V interface{} }{}, false},
+	}
+	for _, tt := range tests {
+		if got := indistinguishable(reflect.TypeOf(tt.v)); got != tt.want {
+			t.Errorf("indistinguishable(%T) = %v; want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	m := map[TStringInt]int{
+		{"b", 1}: 0,
+		{"a", 2}: 1,
+		{"a", 1}: 2,
+		{"c", 0}: 3,
+	}
+	got := SortedKeys(m)
+	want := []TStringInt{{"a", 1}, {"a", 2}, {"b", 1}, {"c", 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("struct keys = %v; want %v", got, want)
+	}
+
+	arrays := map[[2]int]bool{{2, 1}: true, {1, 9}: true, {1, 2}: true}
+	if got, want := SortedKeys(arrays), [][2]int{{1, 2}, {1, 9}, {2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("array keys = %v; want %v", got, want)
+	}
+
+	if got, want := SortedKeys(map[string]int{}), []string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("empty = %#v; want %#v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// sqlNull reports whether t is one of database/sql's nullable types,
+// such as sql.NullString or sql.Null[T]: a struct named Null-something
+// holding a value followed by a Valid bool. If so, it returns the
+// value's field and the offset of Valid.
+//
+// The types are recognized by shape, without importing database/sql.
+func sqlNull(t reflect.Type) (value reflect.StructField, validOff uintptr, ok bool) {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" ||
+		!strings.HasPrefix(t.Name(), "Null") || t.NumField() != 2 {
+		return value, 0, false
+	}
+	valid := t.Field(1)
+	if valid.Name != "Valid" || valid.Type.Kind() != reflect.Bool {
+		return value, 0, false
+	}
+	return t.Field(0), valid.Offset, true
+}
+
+// lessSQLNull returns the leaf for the database/sql nullable type t at
+// path, as found by sqlNull. NULLs (values that aren't Valid) are
+// equal, and order first or, if nilsLast is set, last. Valid values
+// are compared by their value, which is ordered like a field at path.
+func (c *config) lessSQLNull(t reflect.Type, path string, nilsLast bool) func(off uintptr, optEq less) less {
+	value, validOff, _ := sqlNull(t)
+	valueLess := c.forAddr(value.Offset, value.Type, path, nil)
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := *(*bool)(at(a, off+validOff)), *(*bool)(at(b, off+validOff))
+			switch {
+			case va != vb:
+				return va == nilsLast
+			case va && valueLess != nil:
+				pa, pb := at(a, off), at(b, off)
+				if valueLess(pa, pb) {
+					return true
+				}
+				if valueLess(pb, pa) {
+					return false
+				}
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
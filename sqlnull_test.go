@@ -0,0 +1,58 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"database/sql"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSQLNull(t *testing.T) {
+	type row struct {
+		Name  sql.NullString
+		Score sql.NullFloat64
+		ID    int
+	}
+	str := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	f := func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} }
+	in := []row{
+		{str("bob"), f(2), 1},
+		{sql.NullString{String: "zed"}, f(1), 2}, // NULL, despite its String
+		{str("alice"), sql.NullFloat64{}, 3},
+		{str("bob"), f(-1), 4},
+		{sql.NullString{}, f(1), 5},
+		{str(""), f(0), 6},
+	}
+	ids := func() (ret []int) {
+		for _, r := range in {
+			ret = append(ret, r.ID)
+		}
+		return ret
+	}
+	sort.Slice(in, Of(in))
+	if got, want := ids(), []int{2, 5, 6, 3, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	sort.Slice(in, OfOpts(in, NilsLast()))
+	if got, want := ids(), []int{6, 3, 4, 1, 2, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NilsLast: got %v; want %v", got, want)
+	}
+
+	// Options for the field apply to its value.
+	sort.Slice(in, OfOpts(in, StringEnumOrder("Name", []string{"bob", "alice"})))
+	if got, want := ids(), []int{2, 5, 4, 1, 3, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StringEnumOrder: got %v; want %v", got, want)
+	}
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := []sql.NullTime{{Time: t0.Add(time.Hour), Valid: true}, {}, {Time: t0, Valid: true}}
+	sort.Slice(times, Of(times))
+	if times[0].Valid || !times[1].Time.Equal(t0) {
+		t.Errorf("times: got %v", times)
+	}
+}
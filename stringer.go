@@ -0,0 +1,115 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// StringerFallback returns an Option that orders values that would
+// otherwise be ordered by machine address, or by internals their
+// package doesn't export, by the output of their String method
+// instead, so that diagnostic tools get the same order from run to
+// run. It applies to types implementing fmt.Stringer, or whose
+// pointer type does, that have no ordering of their own and are:
+//
+//   - chan, func, map, pointer or unsafe.Pointer types not otherwise
+//     handled by Deref, DerefScalars, DeepMaps or
+//     DeterministicAddresses
+//   - struct types with no exported fields
+//
+// Nil values of pointer-like kinds order first, without calling
+// String. Values with equal strings are equal. String is called twice
+// per comparison, so it should be cheap.
+func StringerFallback() Option {
+	return func(c *config) {
+		c.stringerFallback = true
+		c.applied("StringerFallback")
+	}
+}
+
+// lessStringer returns the leaf for the type t under StringerFallback,
+// or nil if the option doesn't apply to t.
+func (c *config) lessStringer(t reflect.Type) func(off uintptr, optEq less) less {
+	if !c.stringerFallback || !c.opaque(t) {
+		return nil
+	}
+	var key func(p unsafe.Pointer) (string, bool)
+	switch {
+	case t.Implements(stringerType):
+		key = func(p unsafe.Pointer) (string, bool) {
+			return reflect.NewAt(t, p).Elem().Interface().(fmt.Stringer).String(), true
+		}
+	case reflect.PtrTo(t).Implements(stringerType):
+		key = func(p unsafe.Pointer) (string, bool) {
+			return reflect.NewAt(t, p).Interface().(fmt.Stringer).String(), true
+		}
+	default:
+		return nil
+	}
+	c.use("StringerFallback")
+	return lessKeyed(t.Kind() != reflect.Struct, key)
+}
+
+// lessKeyed returns a leaf that orders values by the string key
+// returns for them, with those for which it returns false first. If
+// nilable is set, the values are pointer-shaped, and nil ones order
+// before all others, without calling key.
+func lessKeyed(nilable bool, key func(p unsafe.Pointer) (string, bool)) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			pa, pb := at(a, off), at(b, off)
+			if nilable {
+				na, nb := *(*unsafe.Pointer)(pa) == nil, *(*unsafe.Pointer)(pb) == nil
+				if na != nb {
+					return na
+				}
+				if na {
+					pa = nil
+				}
+			}
+			if pa != nil {
+				ka, okA := key(pa)
+				kb, okB := key(pb)
+				if okA != okB {
+					return !okA
+				}
+				if ka != kb {
+					return ka < kb
+				}
+			}
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+	}
+}
+
+// opaque reports whether c would otherwise order values of type t by
+// machine address or by unexported fields, as described by
+// StringerFallback.
+func (c *config) opaque(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func:
+		return !c.detAddrs
+	case reflect.Map:
+		return !c.deepMaps
+	case reflect.Ptr:
+		return !c.deref && !(c.derefScalars && isScalar(t.Elem()))
+	case reflect.UnsafePointer:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// conn is an opaque type, known by its String output.
+type conn struct {
+	id   int
+	name string
+}
+
+func (c *conn) String() string { return c.name }
+
+// token is an opaque struct with a value String method.
+type token struct{ secret string }
+
+func (t token) String() string { return "token:" + t.secret[:1] }
+
+// label has a String method, but orders by its exported field.
+type label struct{ Name string }
+
+func (l label) String() string { return "label " + l.Name }
+
+func TestStringerFallback(t *testing.T) {
+	type entry struct {
+		Conn *conn
+		N    int
+	}
+	in := []entry{
+		{&conn{1, "db"}, 2},
+		{nil, 5},
+		{&conn{2, "cache"}, 3},
+		{&conn{3, "db"}, 1},
+	}
+	sort.Slice(in, OfOpts(in, StringerFallback()))
+	var got []string
+	for _, e := range in {
+		got = append(got, fmt.Sprintf("%v/%d", e.Conn, e.N))
+	}
+	if want := []string{"<nil>/5", "cache/3", "db/1", "db/2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	// Structs with only unexported fields use String.
+	toks := []token{{"zz"}, {"ab"}, {"ma"}}
+	sort.Slice(toks, OfOpts(toks, StringerFallback()))
+	if want := []token{{"ab"}, {"ma"}, {"zz"}}; !reflect.DeepEqual(toks, want) {
+		t.Errorf("tokens: got %v; want %v", toks, want)
+	}
+
+	// Types without String methods are unaffected.
+	if err := ValidateOpts(reflect.TypeOf(make(chan int)), StringerFallback()); err == nil {
+		t.Error("ValidateOpts: StringerFallback for chan int got nil error")
+	}
+	// Nor are those with exported fields.
+	if err := ValidateOpts(reflect.TypeOf(label{}), StringerFallback()); err == nil {
+		t.Error("ValidateOpts: StringerFallback for a struct with exported fields got nil error")
+	}
+}
@@ -0,0 +1,645 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// StringBySuffix returns an Option that orders the string field at
+// path by its last n runes first, and only then by the whole string.
+// Strings of n runes or fewer are their own suffix.
+//
+// This is useful for ordering email addresses by domain, or file
+// names by extension.
+//
+// StringBySuffix panics if n is not positive.
+func StringBySuffix(path string, n int) Option {
+	if n <= 0 {
+		panic("lesser: StringBySuffix requires a positive length")
+	}
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("StringBySuffix(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				if t.Kind() != reflect.String {
+					return nil
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					sa, sb := runeSuffix(*(*string)(at(a, off)), n), runeSuffix(*(*string)(at(b, off)), n)
+					if sa != sb {
+						return sa < sb
+					}
+					return next(a, b)
+				}
+			},
+		}, n)
+	}
+}
+
+// runeSuffix returns the last n runes of s.
+func runeSuffix(s string, n int) string {
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return s[i:]
+}
+
+// StringIgnoring returns an Option that orders strings as if the
+// runes in chars were removed from them, so "foo-bar", "foo_bar" and
+// "foobar" sort together when chars is "-_". Strings that are equal
+// once stripped are then ordered by their raw values, keeping the
+// ordering total.
+//
+// This is useful for identifiers like SKUs, phone numbers and slugs
+// whose punctuation is cosmetic. Strings are compared in place,
+// without allocating.
+func StringIgnoring(chars string) Option {
+	set := newRuneSet(chars)
+	return func(c *config) {
+		c.ignore = set
+		c.applied("StringIgnoring", chars)
+	}
+}
+
+// Fold returns an Option that orders strings case-insensitively,
+// using simple Unicode case folding, so "apple", "Banana" and "cherry"
+// sort in that order. Strings that differ only in case are then
+// ordered by their raw values, keeping the ordering total.
+//
+// Fold composes with StringIgnoring.
+func Fold() Option {
+	return func(c *config) {
+		c.fold = true
+		c.applied("Fold")
+	}
+}
+
+// ASCIIFold returns an Option that orders strings case-insensitively
+// for ASCII letters only, so "apple", "Banana" and "cherry" sort in
+// that order, while other bytes, including all of non-ASCII UTF-8,
+// compare as they are: "É" and "é" differ. Strings that differ only
+// in case are then ordered by their raw values.
+//
+// Without StringIgnoring, strings are compared a byte at a time, with
+// no UTF-8 decoding or Unicode tables, which makes ASCIIFold faster
+// than Fold. It suits identifiers, hostnames and mostly-English text.
+// Fold takes precedence over ASCIIFold.
+func ASCIIFold() Option {
+	return func(c *config) {
+		c.asciiFold = true
+		c.applied("ASCIIFold")
+	}
+}
+
+// Natural returns an Option that orders strings naturally, comparing
+// runs of ASCII digits by their numeric value, so "file2" orders
+// before "file10" and "host9.example" before "host10.example". Text
+// between the runs compares as usual, and a run of digits compares
+// with other text by its first digit. Numbers can have any number of
+// digits. Strings that are equal this way, such as "a01" and "a1",
+// are then ordered by their raw values.
+//
+// Natural composes with Fold and ASCIIFold. StringIgnoring takes
+// precedence over it.
+func Natural() Option {
+	return func(c *config) {
+		c.natural = true
+		c.applied("Natural")
+	}
+}
+
+// StringOrder returns an Option that orders strings by cmp, which
+// returns a negative number, zero or a positive number if a orders
+// before, the same as or after b. It's meant for orderings this
+// package doesn't provide, such as locale-aware collation; see the
+// collate subpackage. Strings that cmp considers equal are then
+// ordered by their raw values.
+//
+// StringOrder takes precedence over the other string options. Less
+// functions built with it aren't shared between calls, since cmp
+// can't be compared with other functions.
+func StringOrder(cmp func(a, b string) int) Option {
+	return func(c *config) {
+		c.stringCmp = cmp
+		c.uncacheable = true
+		c.applied("StringOrder")
+	}
+}
+
+// runeSet is a set of runes, with a bitmap for ASCII.
+type runeSet struct {
+	ascii [128 / 64]uint64
+	other map[rune]bool
+}
+
+func newRuneSet(chars string) *runeSet {
+	s := new(runeSet)
+	for _, r := range chars {
+		if r < utf8.RuneSelf {
+			s.ascii[r/64] |= 1 << (uint(r) % 64)
+			continue
+		}
+		if s.other == nil {
+			s.other = make(map[rune]bool)
+		}
+		s.other[r] = true
+	}
+	return s
+}
+
+func (s *runeSet) has(r rune) bool {
+	if s == nil {
+		return false
+	}
+	if r < utf8.RuneSelf {
+		return s.ascii[r/64]&(1<<(uint(r)%64)) != 0
+	}
+	return s.other[r]
+}
+
+// cmpRunes compares a and b as if the runes in skip, which may be
+// nil, were removed from both, and with each rune mapped by fold, if
+// non-nil.
+// Since UTF-8 preserves rune order, comparing decoded runes matches
+// comparing the resulting strings byte by byte.
+func cmpRunes(a, b string, skip *runeSet, fold func(rune) rune) int {
+	i, j := 0, 0
+	for {
+		ra, na := skip.next(a, i)
+		rb, nb := skip.next(b, j)
+		switch {
+		case na < 0 && nb < 0:
+			return 0
+		case na < 0:
+			return -1
+		case nb < 0:
+			return 1
+		}
+		if fold != nil {
+			ra, rb = fold(ra), fold(rb)
+		}
+		if ra != rb {
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+		i, j = na, nb
+	}
+}
+
+// foldRune maps r to a canonical case, such that runes equal under
+// simple Unicode case folding map to the same rune.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return r
+	}
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+// foldASCIIRune maps the ASCII upper case letters in r to lower case,
+// for ASCIIFold.
+func foldASCIIRune(r rune) rune {
+	if 'A' <= r && r <= 'Z' {
+		r += 'a' - 'A'
+	}
+	return r
+}
+
+// cmpASCIIFold compares a and b byte by byte, with ASCII letters
+// folded to lower case, as described by ASCIIFold.
+func cmpASCIIFold(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		ca, cb := a[i], b[i]
+		if ca == cb {
+			continue
+		}
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// next returns the first rune of str at or after index i that isn't
+// in s, and the index following it, or -1 if there is none. A nil s
+// is empty.
+func (s *runeSet) next(str string, i int) (rune, int) {
+	for i < len(str) {
+		r, size := rune(str[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRuneInString(str[i:])
+		}
+		i += size
+		if !s.has(r) {
+			return r, i
+		}
+	}
+	return 0, -1
+}
+
+// lessStringRunes returns the string leaf for StringIgnoring, Fold
+// and ASCIIFold, with fold being foldRune, foldASCIIRune or nil.
+// Strings that are equal under them are ordered by their raw values.
+func lessStringRunes(skip *runeSet, fold func(rune) rune) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := *(*string)(at(a, off)), *(*string)(at(b, off))
+			if va == vb {
+				if optEq != nil {
+					return optEq(a, b)
+				}
+				return false
+			}
+			if c := cmpRunes(va, vb, skip, fold); c != 0 {
+				return c < 0
+			}
+			return va < vb
+		}
+	}
+}
+
+// lessStringASCIIFold returns the string leaf for ASCIIFold without
+// StringIgnoring. Strings that are equal under it are ordered by
+// their raw values.
+func lessStringASCIIFold(off uintptr, optEq less) less {
+	return func(a, b unsafe.Pointer) bool {
+		va, vb := *(*string)(at(a, off)), *(*string)(at(b, off))
+		if va == vb {
+			if optEq != nil {
+				return optEq(a, b)
+			}
+			return false
+		}
+		if c := cmpASCIIFold(va, vb); c != 0 {
+			return c < 0
+		}
+		return va < vb
+	}
+}
+
+// lessStringNatural returns the string leaf for Natural, with fold
+// being as for lessStringRunes. Strings that are equal under it are
+// ordered by their raw values.
+func lessStringNatural(fold func(rune) rune) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := *(*string)(at(a, off)), *(*string)(at(b, off))
+			if va == vb {
+				if optEq != nil {
+					return optEq(a, b)
+				}
+				return false
+			}
+			if c := cmpNatural(va, vb, fold); c != 0 {
+				return c < 0
+			}
+			return va < vb
+		}
+	}
+}
+
+// lessStringCmp returns the string leaf for StringOrder. Strings that
+// are equal under cmp are ordered by their raw values.
+func lessStringCmp(cmp func(a, b string) int) func(off uintptr, optEq less) less {
+	return func(off uintptr, optEq less) less {
+		return func(a, b unsafe.Pointer) bool {
+			va, vb := *(*string)(at(a, off)), *(*string)(at(b, off))
+			if va == vb {
+				if optEq != nil {
+					return optEq(a, b)
+				}
+				return false
+			}
+			if c := cmp(va, vb); c != 0 {
+				return c < 0
+			}
+			return va < vb
+		}
+	}
+}
+
+// cmpNatural compares a and b as described by Natural, folding the
+// runes outside digit runs with fold, if non-nil.
+func cmpNatural(a, b string, fold func(rune) rune) int {
+	for a != "" && b != "" {
+		if da, db := digitRun(a), digitRun(b); da > 0 && db > 0 {
+			if c := cmpSegment(a[:da], b[:db]); c != 0 {
+				return c
+			}
+			a, b = a[da:], b[db:]
+			continue
+		}
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if fold != nil {
+			ra, rb = fold(ra), fold(rb)
+		}
+		if ra != rb {
+			return int(ra) - int(rb)
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return len(a) - len(b)
+}
+
+// digitRun returns the length of the run of ASCII digits at the start
+// of s.
+func digitRun(s string) int {
+	i := 0
+	for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// StringEnumOrder returns an Option that orders the string field at
+// path by the position of its value in names, rather than
+// alphabetically. This suits status columns holding enum names, such
+// as StringEnumOrder("Priority", []string{"HIGH", "MEDIUM", "LOW"}).
+//
+// Values not in names order after all those that are, and among
+// themselves by the usual string order. If a name appears more than
+// once, its first position is used.
+func StringEnumOrder(path string, names []string) Option {
+	names = append([]string(nil), names...)
+	rank := make(map[string]int, len(names))
+	for i, name := range names {
+		if _, dup := rank[name]; !dup {
+			rank[name] = i
+		}
+	}
+	unknown := len(names)
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("StringEnumOrder(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				if t.Kind() != reflect.String {
+					return nil
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					ra, ok := rank[*(*string)(at(a, off))]
+					if !ok {
+						ra = unknown
+					}
+					rb, ok := rank[*(*string)(at(b, off))]
+					if !ok {
+						rb = unknown
+					}
+					if ra != rb {
+						return ra < rb
+					}
+					return next(a, b)
+				}
+			},
+		}, names)
+	}
+}
+
+// EnumByName returns an Option that orders the integer field at path
+// by the result of its String method, alphabetically, rather than by
+// its numeric value. This suits enum types with String methods
+// generated by stringer, whose values seldom follow the order their
+// names should be displayed in. Values with equal names are then
+// ordered numerically.
+//
+// The field's type, or a pointer to it, must implement fmt.Stringer;
+// EnumByName doesn't apply to other fields. String is called once per
+// distinct value, and its results are remembered.
+func EnumByName(path string) Option {
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("EnumByName(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				k := t.Kind()
+				if !isInt(k) && !isUint(k) {
+					return nil
+				}
+				byAddr := !t.Implements(stringerType)
+				if byAddr && !reflect.PtrTo(t).Implements(stringerType) {
+					return nil
+				}
+				var names sync.Map // uint64 bits of value => string
+				name := func(p unsafe.Pointer) string {
+					var bits uint64
+					if isInt(k) {
+						bits = uint64(readInt(k, p))
+					} else {
+						bits = readUint(k, p)
+					}
+					if s, ok := names.Load(bits); ok {
+						return s.(string)
+					}
+					v := reflect.NewAt(t, p)
+					if !byAddr {
+						v = v.Elem()
+					}
+					s := v.Interface().(fmt.Stringer).String()
+					names.Store(bits, s)
+					return s
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					if na, nb := name(at(a, off)), name(at(b, off)); na != nb {
+						return na < nb
+					}
+					return next(a, b)
+				}
+			},
+		})
+	}
+}
+
+// BySimhash returns an Option that orders the string field at path by
+// its SimHash first, and only then by the whole string. A SimHash is a
+// 64-bit fingerprint that differs in few bits for similar strings, so
+// sorting by it tends to bring near-duplicates together, ahead of a
+// more expensive pairwise comparison. It's a heuristic: strings that
+// differ in a high bit of their fingerprints land far apart.
+//
+// The fingerprint is built from the FNV-1a hashes of each string's
+// overlapping 3-byte shingles. Each less function computes a distinct
+// string's fingerprint once, and remembers it while the function is
+// in use. Less functions built with BySimhash therefore aren't shared
+// between calls, so the fingerprints don't outlive them.
+func BySimhash(path string) Option {
+	return func(c *config) {
+		c.uncacheable = true
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("BySimhash(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				if t.Kind() != reflect.String {
+					return nil
+				}
+				var memo sync.Map // string => uint64
+				hash := func(p unsafe.Pointer) uint64 {
+					s := *(*string)(p)
+					if h, ok := memo.Load(s); ok {
+						return h.(uint64)
+					}
+					h := simhash(s)
+					memo.Store(s, h)
+					return h
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					if ha, hb := hash(at(a, off)), hash(at(b, off)); ha != hb {
+						return ha < hb
+					}
+					return next(a, b)
+				}
+			},
+		})
+	}
+}
+
+// simhash returns the SimHash of s, as described by BySimhash. Strings
+// shorter than a shingle are a single shingle, and "" hashes to 0.
+func simhash(s string) uint64 {
+	const shingle = 3
+	if s == "" {
+		return 0
+	}
+	var votes [64]int
+	for i := 0; i == 0 || i+shingle <= len(s); i++ {
+		end := i + shingle
+		if end > len(s) {
+			end = len(s)
+		}
+		h := fnvString(fnvOffset, s[i:end])
+		for bit := range votes {
+			if h&(1<<uint(bit)) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+	var h uint64
+	for bit, v := range votes {
+		if v > 0 {
+			h |= 1 << uint(bit)
+		}
+	}
+	return h
+}
+
+// DottedNumeric returns an Option that orders the string field at
+// path as a dotted numeric identifier, such as an OID ("1.3.6.1.4.1")
+// or a version ("1.10.2"). The strings are split on "." and compared
+// segment by segment, so "1.3.6.1.4.9" orders before "1.3.6.1.4.10".
+//
+// Segments of decimal digits compare by numeric value, however many
+// digits they have. Other segments compare as strings, after all
+// numeric ones. A string that is a prefix of another, segment-wise,
+// orders first, as does the empty string. Strings that are equal
+// this way, such as "1.02" and "1.2", are then ordered by their raw
+// values.
+func DottedNumeric(path string) Option {
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("DottedNumeric(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				if t.Kind() != reflect.String {
+					return nil
+				}
+				next := c.forType(off, t, path, optEq)
+				return func(a, b unsafe.Pointer) bool {
+					if c := cmpDotted(*(*string)(at(a, off)), *(*string)(at(b, off))); c != 0 {
+						return c < 0
+					}
+					return next(a, b)
+				}
+			},
+		})
+	}
+}
+
+// cmpDotted compares a and b as described by DottedNumeric. The
+// empty string has no segments, and orders first.
+func cmpDotted(a, b string) int {
+	if a == "" || b == "" {
+		return len(a) - len(b)
+	}
+	for {
+		sa, ra, moreA := cutDot(a)
+		sb, rb, moreB := cutDot(b)
+		if c := cmpSegment(sa, sb); c != 0 {
+			return c
+		}
+		switch {
+		case !moreA && !moreB:
+			return 0
+		case !moreA:
+			return -1
+		case !moreB:
+			return 1
+		}
+		a, b = ra, rb
+	}
+}
+
+// cutDot returns the text of s before the first ".", the text after
+// it, and whether there was one.
+func cutDot(s string) (before, after string, found bool) {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, "", false
+}
+
+// cmpSegment compares two segments of a dotted identifier.
+func cmpSegment(a, b string) int {
+	na, nb := isDigits(a), isDigits(b)
+	switch {
+	case na && nb:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+	case na:
+		return -1
+	case nb:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,391 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"math/bits"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type contact struct {
+	Email string
+}
+
+func TestStringBySuffix(t *testing.T) {
+	in := []contact{
+		{"zed@b.org"},
+		{"amy@c.com"},
+		{"bob@a.org"},
+		{"org"},
+		{"kim@c.com"},
+		{"x"},
+	}
+	sort.Slice(in, OfOpts(in, StringBySuffix("Email", 3)))
+	var got []string
+	for _, c := range in {
+		got = append(got, c.Email)
+	}
+	want := []string{"amy@c.com", "kim@c.com", "bob@a.org", "org", "zed@b.org", "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestRuneSuffix(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 2, "lo"},
+		{"hi", 5, "hi"},
+		{"", 1, ""},
+		{"naïve", 3, "ïve"},
+		{"日本語", 1, "語"},
+	}
+	for _, tt := range tests {
+		if got := runeSuffix(tt.s, tt.n); got != tt.want {
+			t.Errorf("runeSuffix(%q, %d) = %q; want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestStringIgnoring(t *testing.T) {
+	in := []contact{
+		{"foo_bar"},
+		{"foobaz"},
+		{"foo-bar"},
+		{"foobar"},
+		{"fo-o"},
+		{"--"},
+		{"é-1"},
+		{"é1"},
+	}
+	sort.Slice(in, OfOpts(in, StringIgnoring("-_")))
+	var got []string
+	for _, c := range in {
+		got = append(got, c.Email)
+	}
+	want := []string{"--", "fo-o", "foo-bar", "foo_bar", "foobar", "foobaz", "é-1", "é1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestCmpRunes(t *testing.T) {
+	set := newRuneSet("-. ·")
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"555-1234", "555 12.34", 0},
+		{"a·b", "ab", 0},
+		{"a-", "a", 0},
+		{"a", "a-b", -1},
+		{"b", "-a", 1},
+		{"日-本", "日本語", -1},
+	}
+	for _, tt := range tests {
+		if got := cmpRunes(tt.a, tt.b, set, nil); got != tt.want {
+			t.Errorf("cmpRunes(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestStringEnumOrder(t *testing.T) {
+	type ticket struct {
+		Priority string
+		ID       int
+	}
+	in := []ticket{
+		{"LOW", 1},
+		{"bogus", 2},
+		{"HIGH", 3},
+		{"MEDIUM", 4},
+		{"HIGH", 1},
+		{"", 5},
+		{"LOW", 0},
+	}
+	sort.Slice(in, OfOpts(in, StringEnumOrder("Priority", []string{"HIGH", "MEDIUM", "LOW"})))
+	want := []ticket{
+		{"HIGH", 1},
+		{"HIGH", 3},
+		{"MEDIUM", 4},
+		{"LOW", 0},
+		{"LOW", 1},
+		{"", 5},
+		{"bogus", 2},
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v\nwant %v", in, want)
+	}
+}
+
+func TestFold(t *testing.T) {
+	in := []contact{{"cherry"}, {"Banana"}, {"apple"}, {"banana"}, {"ÉCLAIR"}, {"éclair"}, {"Apple-Pie"}}
+	sort.Slice(in, OfOpts(in, Fold()))
+	var got []string
+	for _, c := range in {
+		got = append(got, c.Email)
+	}
+	want := []string{"apple", "Apple-Pie", "Banana", "banana", "cherry", "ÉCLAIR", "éclair"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	sort.Slice(in, OfOpts(in, Fold(), StringIgnoring("-")))
+	if in[1].Email != "Apple-Pie" || in[0].Email != "apple" {
+		t.Errorf("with StringIgnoring: got %q, %q first", in[0].Email, in[1].Email)
+	}
+}
+
+func TestFoldTieBreak(t *testing.T) {
+	ss := []string{"b", "A", "a", "B"}
+	sort.Slice(ss, OfOpts(ss, Fold()))
+	if want := []string{"A", "a", "B", "b"}; !reflect.DeepEqual(ss, want) {
+		t.Errorf("got %q; want %q", ss, want)
+	}
+
+	// Names equal but for case order by their raw values before
+	// the next field is compared.
+	type user struct {
+		Name string
+		N    int
+	}
+	in := []user{{"foo", 1}, {"Foo", 2}, {"bar", 3}, {"foo", 0}, {"FOO", 9}}
+	sort.Slice(in, OfOpts(in, Fold()))
+	want := []user{{"bar", 3}, {"FOO", 9}, {"Foo", 2}, {"foo", 0}, {"foo", 1}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
+
+func TestASCIIFold(t *testing.T) {
+	in := []contact{{"cherry"}, {"Banana"}, {"apple"}, {"banana"}, {"éclair"}, {"ÉCLAIR"}, {"Apple-Pie"}, {"[x]"}}
+	sort.Slice(in, OfOpts(in, ASCIIFold()))
+	var got []string
+	for _, c := range in {
+		got = append(got, c.Email)
+	}
+	// "[" sorts between the upper and lower case letters, so
+	// folding moves it before them all. "É" and "é" don't fold.
+	want := []string{"[x]", "apple", "Apple-Pie", "Banana", "banana", "cherry", "ÉCLAIR", "éclair"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	sort.Slice(in, OfOpts(in, ASCIIFold(), StringIgnoring("-[]")))
+	got = got[:0]
+	for _, c := range in {
+		got = append(got, c.Email)
+	}
+	want = []string{"apple", "Apple-Pie", "Banana", "banana", "cherry", "[x]", "ÉCLAIR", "éclair"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("with StringIgnoring: got %q\nwant %q", got, want)
+	}
+}
+
+func TestNatural(t *testing.T) {
+	in := []string{"file10.log", "file2.log", "File3.log", "file02.log", "file", "host9", "host10a", "host10", "file2.log.1", "x99999999999999999999999", "x100000000000000000000000"}
+	sort.Slice(in, OfOpts(in, Natural()))
+	want := []string{"File3.log", "file", "file02.log", "file2.log", "file2.log.1", "file10.log", "host9", "host10", "host10a", "x99999999999999999999999", "x100000000000000000000000"}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %q\nwant %q", in, want)
+	}
+
+	sort.Slice(in, OfOpts(in, Natural(), Fold()))
+	want = []string{"file", "file02.log", "file2.log", "file2.log.1", "File3.log", "file10.log", "host9", "host10", "host10a", "x99999999999999999999999", "x100000000000000000000000"}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("with Fold: got %q\nwant %q", in, want)
+	}
+}
+
+func TestCmpNatural(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"a2", "a10", -1},
+		{"a10", "a2", 1},
+		{"a01", "a1", 0},
+		{"a1b", "a1c", -1},
+		{"a1", "a1b", -1},
+		{"a1", "ab", -1}, // '1' < 'b'
+		{"1.5", "1.10", -1},
+		{"", "0", -1},
+		{"é2", "é10", -1},
+	}
+	for _, tt := range tests {
+		got := cmpNatural(tt.a, tt.b, nil)
+		if got < 0 {
+			got = -1
+		} else if got > 0 {
+			got = 1
+		}
+		if got != tt.want {
+			t.Errorf("cmpNatural(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestStringOrder(t *testing.T) {
+	// Order by length, then raw value.
+	byLen := func(a, b string) int { return len(a) - len(b) }
+	in := []contact{{"bb"}, {"a"}, {"ccc"}, {"ab"}}
+	sort.Slice(in, OfOpts(in, StringOrder(byLen), Fold()))
+	if want := []contact{{"a"}, {"ab"}, {"bb"}, {"ccc"}}; !reflect.DeepEqual(in, want) {
+		t.Errorf("got %q; want %q", in, want)
+	}
+	if err := ValidateOpts(reflect.TypeOf(contact{}), StringOrder(byLen), Fold()); err == nil {
+		t.Error("ValidateOpts: Fold with StringOrder got nil error")
+	}
+}
+
+func benchmarkFold(b *testing.B, opt Option) {
+	words := []string{"Alpha", "bravo", "CHARLIE", "delta", "Echo", "foxtrot", "Golf", "hotel"}
+	in := make([]contact, 1000)
+	for i := range in {
+		in[i].Email = words[i%len(words)] + "_" + words[i*7%len(words)]
+	}
+	s := make([]contact, len(in))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(s, in)
+		sort.Slice(s, OfOpts(s, opt))
+	}
+}
+
+func BenchmarkFold(b *testing.B)      { benchmarkFold(b, Fold()) }
+func BenchmarkASCIIFold(b *testing.B) { benchmarkFold(b, ASCIIFold()) }
+
+func TestDottedNumeric(t *testing.T) {
+	in := []contact{
+		{"1.3.6.1.4.10"},
+		{"1.3.6.1.4.9"},
+		{"1.3.6.1.4"},
+		{"1.3.6.1.4.1.99999999999999999999999"},
+		{"1.3.6.1.4.1.100000000000000000000000"},
+		{"1.3.6.1.4.1.x"},
+		{"1.3.6.1.4.01"},
+		{"1.3.6.1.4.1"},
+		{"2"},
+		{""},
+	}
+	sort.Slice(in, OfOpts(in, DottedNumeric("Email")))
+	var got []string
+	for _, c := range in {
+		got = append(got, c.Email)
+	}
+	want := []string{
+		"",
+		"1.3.6.1.4",
+		"1.3.6.1.4.01",
+		"1.3.6.1.4.1",
+		"1.3.6.1.4.1.99999999999999999999999",
+		"1.3.6.1.4.1.100000000000000000000000",
+		"1.3.6.1.4.1.x",
+		"1.3.6.1.4.9",
+		"1.3.6.1.4.10",
+		"2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+// fruit is an enum with a stringer-style String method.
+type fruit uint8
+
+const (
+	cherry fruit = iota
+	apple
+	banana
+)
+
+const fruitNames = "cherryapplebanana"
+
+var fruitIndex = [...]uint8{0, 6, 11, 17}
+
+func (f fruit) String() string {
+	if int(f) >= len(fruitIndex)-1 {
+		return "fruit(" + string(rune('0'+f)) + ")"
+	}
+	return fruitNames[fruitIndex[f]:fruitIndex[f+1]]
+}
+
+func TestEnumByName(t *testing.T) {
+	type basket struct {
+		Fruit fruit
+		N     int
+	}
+	in := []basket{{banana, 1}, {cherry, 2}, {apple, 3}, {cherry, 1}, {5, 0}}
+	sort.Slice(in, OfOpts(in, EnumByName("Fruit")))
+	want := []basket{{apple, 3}, {banana, 1}, {cherry, 1}, {cherry, 2}, {5, 0}}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+
+	sort.Slice(in, Of(in))
+	if in[0].Fruit != cherry {
+		t.Errorf("without EnumByName, first fruit is %v; want %v", in[0].Fruit, cherry)
+	}
+
+	if err := ValidateOpts(reflect.TypeOf(basket{}), EnumByName("N")); err == nil {
+		t.Error("EnumByName on a field without String: got nil error")
+	}
+}
+
+func TestBySimhash(t *testing.T) {
+	in := []contact{
+		{"the quick brown fox jumps over the lazy dog"},
+		{"lorem ipsum dolor sit amet, consectetur"},
+		{"the quick brown fox jumped over the lazy dog"},
+		{"pack my box with five dozen liquor jugs"},
+		{"lorem ipsum dolor sit amet, consectetuer"},
+		{""},
+		{"the quick brown fox jumps over the lazy dog"},
+	}
+	sort.Slice(in, OfOpts(in, BySimhash("Email")))
+	// The ordering remembers fingerprints, so it mustn't be cached
+	// for the life of the process.
+	c := newConfig([]Option{BySimhash("Email")})
+	key := lessKey{t: reflect.TypeOf(contact{}), opts: strings.Join(c.key, "\x00"), gen: registryGeneration()}
+	if _, ok := lessCache.Load(key); ok {
+		t.Error("BySimhash ordering was cached")
+	}
+	if in[0].Email != "" {
+		t.Errorf("first = %q; want empty string", in[0].Email)
+	}
+	for i := 1; i < len(in); i++ {
+		a, b := in[i-1].Email, in[i].Email
+		if simhash(a) == simhash(b) && a > b {
+			t.Errorf("equal fingerprints not ordered by string: %q, %q", a, b)
+		}
+	}
+
+	// Fingerprints of similar strings differ in fewer bits than
+	// those of unrelated ones.
+	const (
+		fox1   = "the quick brown fox jumps over the lazy dog"
+		fox2   = "the quick brown fox jumped over the lazy dog"
+		lorem1 = "lorem ipsum dolor sit amet, consectetur"
+		lorem2 = "lorem ipsum dolor sit amet, consectetuer"
+	)
+	dist := func(a, b string) int { return bits.OnesCount64(simhash(a) ^ simhash(b)) }
+	for _, similar := range [][2]string{{fox1, fox2}, {lorem1, lorem2}} {
+		for _, other := range []string{fox1, lorem1} {
+			if other == similar[0] {
+				continue
+			}
+			if d, far := dist(similar[0], similar[1]), dist(similar[0], other); d >= far {
+				t.Errorf("%q is %d bits from %q, but %d from %q", similar[0], d, similar[1], far, other)
+			}
+		}
+	}
+}
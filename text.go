@@ -0,0 +1,58 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"encoding"
+	"reflect"
+	"unsafe"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// ByText returns an Option that orders values of types implementing
+// encoding.TextMarshaler, or whose pointer types do, by their
+// marshaled text, compared as strings. This gives a sensible order to
+// many types from other packages, such as UUIDs and custom IDs,
+// without an ordering for each. It doesn't apply to types with an
+// ordering of their own, such as those with a Cmp method, time.Time
+// and netip.Addr.
+//
+// Values whose MarshalText returns an error order before all others,
+// and nil pointers, maps, chans and funcs before those. MarshalText is
+// called twice per comparison.
+func ByText() Option {
+	return func(c *config) {
+		c.byText = true
+		c.applied("ByText")
+	}
+}
+
+// lessText returns the leaf for the type t under ByText, or nil if the
+// option doesn't apply to t.
+func (c *config) lessText(t reflect.Type) func(off uintptr, optEq less) less {
+	if !c.byText {
+		return nil
+	}
+	marshal := func(v reflect.Value) (string, bool) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err == nil
+	}
+	var key func(p unsafe.Pointer) (string, bool)
+	switch {
+	case t.Implements(textMarshalerType):
+		key = func(p unsafe.Pointer) (string, bool) { return marshal(reflect.NewAt(t, p).Elem()) }
+	case reflect.PtrTo(t).Implements(textMarshalerType):
+		key = func(p unsafe.Pointer) (string, bool) { return marshal(reflect.NewAt(t, p)) }
+	default:
+		return nil
+	}
+	c.use("ByText")
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.UnsafePointer:
+		return lessKeyed(true, key)
+	}
+	return lessKeyed(false, key)
+}
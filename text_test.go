@@ -0,0 +1,62 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sku marshals to text that orders differently than its fields do.
+type sku struct {
+	Num  int
+	Kind string
+}
+
+func (s sku) MarshalText() ([]byte, error) {
+	if s.Kind == "" {
+		return nil, errors.New("no kind")
+	}
+	return []byte(fmt.Sprintf("%s-%04d", s.Kind, s.Num)), nil
+}
+
+// ticket has a MarshalText method on its pointer type.
+type ticket struct{ ID int }
+
+func (t *ticket) MarshalText() ([]byte, error) { return []byte(fmt.Sprint("T", t.ID)), nil }
+
+func TestByText(t *testing.T) {
+	skus := []sku{{1, "pear"}, {2, "apple"}, {3, ""}, {10, "apple"}, {0, ""}}
+	sort.Slice(skus, OfOpts(skus, ByText()))
+	// Those that fail to marshal are equal, so the sort may
+	// leave them in either order.
+	if skus[0].Kind != "" || skus[1].Kind != "" {
+		t.Errorf("errors not first: %v", skus)
+	}
+	if want := []sku{{2, "apple"}, {10, "apple"}, {1, "pear"}}; !reflect.DeepEqual(skus[2:], want) {
+		t.Errorf("got %v; want %v", skus[2:], want)
+	}
+
+	// Text compares as strings, so "T10" orders before "T9".
+	tickets := []*ticket{{9}, nil, {10}}
+	sort.Slice(tickets, OfOpts(tickets, ByText()))
+	if tickets[0] != nil || tickets[1].ID != 10 || tickets[2].ID != 9 {
+		t.Errorf("tickets: got %v", tickets)
+	}
+	byValue := []ticket{{9}, {10}}
+	sort.Slice(byValue, OfOpts(byValue, ByText()))
+	if byValue[0].ID != 10 {
+		t.Errorf("ticket values: got %v", byValue)
+	}
+
+	// Types with their own ordering keep it.
+	if err := ValidateOpts(reflect.TypeOf(big.NewFloat(0)), ByText()); err == nil {
+		t.Error("ValidateOpts: ByText for *big.Float got nil error")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func init() {
+	// time.Time's fields don't order chronologically, so compare
+	// with its methods instead.
+	register(timeType, cmpTime)
+}
+
+func cmpTime(a, b unsafe.Pointer) int {
+	ta, tb := (*time.Time)(a), (*time.Time)(b)
+	switch {
+	case ta.Before(*tb):
+		return -1
+	case ta.After(*tb):
+		return 1
+	}
+	return 0
+}
+
+// TimeBucket returns an Option that orders the time.Time field at
+// path by the interval of length d it falls in, so that times in the
+// same interval are equal and the ordering falls through to the next
+// field. For example, TimeBucket("At", 15*time.Minute) groups events
+// into quarter hours.
+//
+// Intervals are anchored at the Unix epoch: a time t falls in
+// interval floor((t - epoch) / d). Unlike time.Time.Truncate, which
+// is anchored at the zero Time, this puts interval boundaries on the
+// hour for any d that divides an hour, even before 1970. Time zones
+// don't matter. The zero Time is in an interval of its own, before
+// all others, so unset times never group with real ones.
+//
+// TimeBucket panics if d is not positive.
+func TimeBucket(path string, d time.Duration) Option {
+	if d <= 0 {
+		panic("lesser: TimeBucket requires a positive duration")
+	}
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("TimeBucket(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				if t != timeType {
+					return nil
+				}
+				return func(a, b unsafe.Pointer) bool {
+					if c := cmpTimeBucket(*(*time.Time)(at(a, off)), *(*time.Time)(at(b, off)), d); c != 0 {
+						return c < 0
+					}
+					if optEq != nil {
+						return optEq(a, b)
+					}
+					return false
+				}
+			},
+		}, d)
+	}
+}
+
+// TimeBucketInt is like TimeBucket, but for an integer field holding a
+// timestamp: a count of unit since the Unix epoch, as with Unix
+// seconds (time.Second) or milliseconds (time.Millisecond). Values
+// are grouped into the same epoch-anchored intervals of length d as
+// TimeBucket would group the times they stand for, so the two agree.
+// Zero is the epoch itself, not an unset time.
+//
+// The intervals are computed exactly, whatever unit and d are.
+// TimeBucketInt panics if unit or d is not positive.
+func TimeBucketInt(path string, unit, d time.Duration) Option {
+	if unit <= 0 || d <= 0 {
+		panic("lesser: TimeBucketInt requires a positive unit and duration")
+	}
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("TimeBucketInt(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				k := t.Kind()
+				if !isInt(k) && !isUint(k) {
+					return nil
+				}
+				return func(a, b unsafe.Pointer) bool {
+					if c := cmpIntTimeBucket(k, at(a, off), at(b, off), unit, d); c != 0 {
+						return c < 0
+					}
+					if optEq != nil {
+						return optEq(a, b)
+					}
+					return false
+				}
+			},
+		}, unit, d)
+	}
+}
+
+// cmpIntTimeBucket compares the intervals of length d holding the
+// timestamps, in unit, of kind k at pa and pb, as described by
+// TimeBucketInt.
+func cmpIntTimeBucket(k reflect.Kind, pa, pb unsafe.Pointer, unit, d time.Duration) int {
+	if d%unit == 0 {
+		// Each interval is a whole number of units, as usual.
+		per := int64(d / unit)
+		if isInt(k) {
+			return cmpInt64(floorDiv(readInt(k, pa), per), floorDiv(readInt(k, pb), per))
+		}
+		qa, qb := readUint(k, pa)/uint64(per), readUint(k, pb)/uint64(per)
+		switch {
+		case qa < qb:
+			return -1
+		case qa > qb:
+			return 1
+		}
+		return 0
+	}
+	return bigIntTimeBucket(k, pa, unit, d).Cmp(bigIntTimeBucket(k, pb, unit, d))
+}
+
+// bigIntTimeBucket returns the interval of length d holding the
+// timestamp, in unit, of kind k at p.
+func bigIntTimeBucket(k reflect.Kind, p unsafe.Pointer, unit, d time.Duration) *big.Int {
+	var n *big.Int
+	if isInt(k) {
+		n = big.NewInt(readInt(k, p))
+	} else {
+		n = new(big.Int).SetUint64(readUint(k, p))
+	}
+	n.Mul(n, big.NewInt(int64(unit)))
+	return n.Div(n, big.NewInt(int64(d)))
+}
+
+// maxNanoSec is the largest number of seconds from the Unix epoch
+// whose nanosecond count fits in an int64.
+const maxNanoSec = math.MaxInt64/int64(time.Second) - 1
+
+// cmpTimeBucket compares the intervals of length d, as described by
+// TimeBucket, holding a and b.
+func cmpTimeBucket(a, b time.Time, d time.Duration) int {
+	if za, zb := a.IsZero(), b.IsZero(); za || zb {
+		switch {
+		case za && zb:
+			return 0
+		case za:
+			return -1
+		}
+		return 1
+	}
+	sa, sb := a.Unix(), b.Unix()
+	if -maxNanoSec < sa && sa < maxNanoSec && -maxNanoSec < sb && sb < maxNanoSec {
+		na := sa*int64(time.Second) + int64(a.Nanosecond())
+		nb := sb*int64(time.Second) + int64(b.Nanosecond())
+		return cmpInt64(floorDiv(na, int64(d)), floorDiv(nb, int64(d)))
+	}
+	return bigTimeBucket(a, d).Cmp(bigTimeBucket(b, d))
+}
+
+// floorDiv returns a/b rounded toward negative infinity, for b > 0.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+// bigTimeBucket returns the interval of length d holding t, for times
+// too far from the Unix epoch to count in int64 nanoseconds.
+func bigTimeBucket(t time.Time, d time.Duration) *big.Int {
+	n := big.NewInt(t.Unix())
+	n.Mul(n, big.NewInt(int64(time.Second)))
+	n.Add(n, big.NewInt(int64(t.Nanosecond())))
+	// Div rounds toward negative infinity for positive divisors.
+	return n.Div(n, big.NewInt(int64(d)))
+}
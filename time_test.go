@@ -0,0 +1,174 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestTimeArrayField(t *testing.T) {
+	type span struct {
+		ID    int
+		Times [2]time.Time
+	}
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return t0.Add(d) }
+
+	// Nanoseconds differ in the opposite direction of seconds,
+	// which a field-by-field comparison of time.Time gets wrong.
+	in := []span{
+		{1, [2]time.Time{at(time.Second), at(0)}},
+		{1, [2]time.Time{at(999 * time.Millisecond), at(time.Hour)}},
+		{1, [2]time.Time{at(time.Second), at(-time.Nanosecond)}},
+		{0, [2]time.Time{at(time.Hour), at(0)}},
+	}
+	want := []span{in[3], in[1], in[2], in[0]}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("wrong:\n got: %v\nwant: %v", in, want)
+	}
+}
+
+func TestTimeRepresentations(t *testing.T) {
+	type event struct {
+		At   time.Time
+		Name string
+	}
+	now := time.Now() // has a monotonic reading
+	wall := now.Round(0)
+	east := time.FixedZone("east", 9*60*60)
+
+	// The same instant with and without a monotonic reading, or in
+	// another location, is equal, so Name breaks the tie.
+	in := []event{
+		{now.Add(time.Second), "d"},
+		{now, "c"},
+		{wall.In(east), "a"},
+		{wall.Add(-time.Nanosecond).In(time.UTC), "z"},
+		{wall, "b"},
+	}
+	var got []string
+	sort.Slice(in, Of(in))
+	for _, e := range in {
+		got = append(got, e.Name)
+	}
+	if want := []string{"z", "a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	times := []time.Time{now.Add(time.Minute), wall.In(east), now.Add(-time.Minute)}
+	sort.Slice(times, Of(times))
+	for i := 1; i < len(times); i++ {
+		if times[i].Before(times[i-1]) {
+			t.Fatalf("not chronological: %v", times)
+		}
+	}
+}
+
+func TestCmpMethodArray(t *testing.T) {
+	in := [][2]revInt{{{1}, {2}}, {{1}, {3}}, {{2}, {0}}}
+	want := [][2]revInt{{{2}, {0}}, {{1}, {3}}, {{1}, {2}}}
+	sort.Slice(in, Of(in))
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("got %v; want %v", in, want)
+	}
+}
+
+func TestTimeBucket(t *testing.T) {
+	type event struct {
+		At time.Time
+		ID int
+	}
+	base := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	in := []event{
+		{base.Add(20 * time.Minute), 1},
+		{base.Add(14 * time.Minute), 2},
+		{base.Add(-time.Nanosecond), 3},
+		{base.Add(16 * time.Minute), 0},
+		{time.Time{}, 9},
+		{base, 5},
+		{base.Add(29*time.Minute + 59*time.Second), -1},
+	}
+	sort.Slice(in, OfOpts(in, TimeBucket("At", 15*time.Minute)))
+	var got []int
+	for _, e := range in {
+		got = append(got, e.ID)
+	}
+	// Zero, then 11:45-12:00, 12:00-12:15 and 12:15-12:30, each by ID.
+	if want := []int{9, 3, 2, 5, -1, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestTimeBucketInt(t *testing.T) {
+	type row struct {
+		AtMillis int64
+		Seen     uint32 // Unix seconds
+		ID       int
+	}
+	base := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	millis := func(d time.Duration) int64 { return base.Add(d).UnixNano() / int64(time.Millisecond) }
+	in := []row{
+		{millis(20 * time.Minute), 0, 1},
+		{millis(14 * time.Minute), 0, 2},
+		{millis(-time.Millisecond), 0, 3},
+		{millis(16 * time.Minute), 0, 0},
+		{0, 0, 9},
+		{-1, 0, 8},
+		{millis(0), 0, 5},
+	}
+	sort.Slice(in, OfOpts(in, TimeBucketInt("AtMillis", time.Millisecond, 15*time.Minute)))
+	var got []int
+	for _, r := range in {
+		got = append(got, r.ID)
+	}
+	// Before the epoch, the epoch, then 11:45-12:00, 12:00-12:15
+	// and 12:15-12:30, each by ID.
+	if want := []int{8, 9, 3, 2, 5, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	// Intervals that aren't a whole number of units.
+	in = []row{{0, 5, 2}, {0, 2, 3}, {0, 3, 1}, {0, 1, 0}}
+	sort.Slice(in, OfOpts(in, TimeBucketInt("Seen", time.Second, 1500*time.Millisecond)))
+	got = got[:0]
+	for _, r := range in {
+		got = append(got, r.ID)
+	}
+	// 1, 2, 3 and 5 seconds fall in consecutive 1.5s intervals.
+	if want := []int{0, 3, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestCmpTimeBucket(t *testing.T) {
+	hour := time.Hour
+	tests := []struct {
+		a, b time.Time
+		d    time.Duration
+		want int
+	}{
+		// Anchored at the Unix epoch, also before it.
+		{time.Unix(-1, 0), time.Unix(-3600, 0), hour, 0},
+		{time.Unix(-1, 0), time.Unix(0, 0), hour, -1},
+		// Time zones don't matter.
+		{time.Date(2020, 1, 1, 10, 5, 0, 0, time.UTC), time.Date(2020, 1, 1, 6, 55, 0, 0, time.FixedZone("", -4*3600)), hour, 0},
+		// Far from the epoch.
+		{time.Date(1, 1, 1, 0, 0, 1, 0, time.UTC), time.Date(1, 1, 1, 0, 59, 0, 0, time.UTC), hour, 0},
+		{time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(9999, 1, 1, 0, 59, 0, 0, time.UTC), hour, 0},
+		{time.Date(1, 1, 1, 0, 0, 1, 0, time.UTC), time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), hour, -1},
+		// The zero Time is never in a real interval.
+		{time.Time{}, time.Date(1, 1, 1, 0, 0, 1, 0, time.UTC), 24 * hour, -1},
+		{time.Time{}, time.Time{}, hour, 0},
+	}
+	for i, tt := range tests {
+		if got := cmpTimeBucket(tt.a, tt.b, tt.d); got != tt.want {
+			t.Errorf("%d. cmpTimeBucket(%v, %v, %v) = %d; want %d", i, tt.a, tt.b, tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// TransformField returns an Option that orders the field at path by
+// the result of fn on its value, rather than by the value itself, as
+// in lowercasing a string, taking the absolute value of an integer,
+// or extracting the year from a time.Time. The results are ordered
+// by the rules of Of for a slice of interface{} values: by type, and
+// then by value. Fields with equal results are equal, and the
+// ordering falls through to the next field.
+//
+// The value passed to fn must not be modified. If fn returns the zero
+// reflect.Value, the result orders like a nil interface, first.
+//
+// By default fn is called twice per comparison. With WithCache, its
+// results are remembered instead, keyed by the field's value.
+func TransformField(path string, fn func(reflect.Value) reflect.Value) Option {
+	return func(c *config) {
+		c.setField(path, fieldRule{
+			name: fmt.Sprintf("TransformField(%q)", path),
+			build: func(c *config, off uintptr, t reflect.Type, path string, optEq less) less {
+				return c.lessTransform(off, t, fn, optEq)
+			},
+		})
+		c.uncacheable = true // fn can't be encoded
+	}
+}
+
+// WithCache returns an Option that makes TransformField remember the
+// result of its function for each distinct field value, so it's
+// called about once per element rather than on every comparison. The
+// function must then be deterministic, and the field's type must be
+// comparable; WithCache doesn't apply to fields of other types.
+func WithCache() Option {
+	return func(c *config) {
+		c.withCache = true
+		c.applied("WithCache")
+	}
+}
+
+func (c *config) lessTransform(off uintptr, t reflect.Type, fn func(reflect.Value) reflect.Value, optEq less) less {
+	apply := func(p unsafe.Pointer) interface{} {
+		v := fn(reflect.NewAt(t, p).Elem())
+		if !v.IsValid() {
+			return nil
+		}
+		return v.Interface()
+	}
+	if c.withCache && t.Comparable() {
+		c.use("WithCache")
+		uncached := apply
+		var memo sync.Map // field value => result
+		apply = func(p unsafe.Pointer) interface{} {
+			v := reflect.NewAt(t, p).Elem()
+			if t.Kind() == reflect.Interface && !v.IsNil() && !v.Elem().Type().Comparable() {
+				return uncached(p)
+			}
+			k := v.Interface()
+			if r, ok := memo.Load(k); ok {
+				return r
+			}
+			r := uncached(p)
+			memo.Store(k, r)
+			return r
+		}
+	}
+	cmp := newConfig(nil).forAddr(0, emptyIfaceType, "", nil)
+	return func(a, b unsafe.Pointer) bool {
+		ra, rb := apply(at(a, off)), apply(at(b, off))
+		if cmp(unsafe.Pointer(&ra), unsafe.Pointer(&rb)) {
+			return true
+		}
+		if cmp(unsafe.Pointer(&rb), unsafe.Pointer(&ra)) {
+			return false
+		}
+		if optEq != nil {
+			return optEq(a, b)
+		}
+		return false
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransformField(t *testing.T) {
+	type reading struct {
+		Delta int
+		ID    string
+	}
+	abs := func(v reflect.Value) reflect.Value {
+		if n := v.Int(); n < 0 {
+			return reflect.ValueOf(-n)
+		}
+		return reflect.ValueOf(v.Int())
+	}
+	in := []reading{{-5, "a"}, {3, "b"}, {-1, "c"}, {5, "d"}, {0, "e"}, {-3, "f"}}
+	sort.Slice(in, OfOpts(in, TransformField("Delta", abs)))
+	var got []string
+	for _, r := range in {
+		got = append(got, r.ID)
+	}
+	if want := []string{"e", "c", "b", "f", "a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestTransformFieldWithCache(t *testing.T) {
+	type event struct {
+		At  time.Time
+		Tag string
+	}
+	calls := 0
+	lower := func(v reflect.Value) reflect.Value {
+		calls++
+		return reflect.ValueOf(strings.ToLower(v.String()))
+	}
+	year := func(v reflect.Value) reflect.Value {
+		return reflect.ValueOf(v.Interface().(time.Time).Year())
+	}
+	date := func(y int) time.Time { return time.Date(y, 6, 1, 0, 0, 0, 0, time.UTC) }
+	in := []event{
+		{date(2020).Add(time.Hour), "b"},
+		{date(2019), "Z"},
+		{date(2020), "A"},
+		{date(2020), "c"},
+		{date(2020), "a"},
+	}
+	sort.SliceStable(in, OfOpts(in, TransformField("At", year), TransformField("Tag", lower), WithCache()))
+	var got []string
+	for _, e := range in {
+		got = append(got, e.Tag)
+	}
+	// By year only, then by lowercased tag; equal tags stay in order.
+	if want := []string{"Z", "A", "a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if distinct := 5; calls > distinct {
+		t.Errorf("lower called %d times; want at most %d with WithCache", calls, distinct)
+	}
+}
+
+func TestTransformFieldInvalid(t *testing.T) {
+	none := func(v reflect.Value) reflect.Value {
+		if v.Len() == 0 {
+			return reflect.Value{}
+		}
+		return reflect.ValueOf(v.Index(0).Int())
+	}
+	// []int isn't orderable by Of, but its transform is, and isn't
+	// comparable, so WithCache doesn't apply.
+	in := []struct{ S []int }{{[]int{2}}, {nil}, {[]int{1, 9}}}
+	sort.Slice(in, OfOpts(in, TransformField("S", none), WithCache()))
+	if in[0].S != nil || in[1].S[0] != 1 || in[2].S[0] != 2 {
+		t.Errorf("got %v", in)
+	}
+}
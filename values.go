@@ -0,0 +1,56 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// OfValues returns a less function for sorting vals, whose elements
+// must all be valid and share a type, ordering them as OfOpts would a
+// slice of that type. It returns an error if they don't share one, or
+// values of that type can't be ordered.
+//
+// Unlike the functions for slices, the returned function reads
+// vals[i] and vals[j] anew on each call, so it orders vals itself, as
+// sort.Slice swaps them. Values that aren't addressable are copied
+// before they're compared.
+//
+// Options whose orderings depend on a particular slice, such as
+// DerefStable, TieBreak and DeterministicAddresses, have no effect.
+func OfValues(vals []reflect.Value, opts ...Option) (less func(i, j int) bool, err error) {
+	if len(vals) == 0 {
+		return nil, nil // won't be called
+	}
+	var t reflect.Type
+	for i, v := range vals {
+		if !v.IsValid() {
+			return nil, fmt.Errorf("lesser: OfValues: value %d is invalid", i)
+		}
+		if i == 0 {
+			t = v.Type()
+		} else if v.Type() != t {
+			return nil, fmt.Errorf("lesser: OfValues: value %d is of type %v, not %v like value 0", i, v.Type(), t)
+		}
+	}
+	c := newConfig(opts)
+	elemLess := c.cachedLess(t)
+	if c.err != nil {
+		return nil, c.err
+	}
+	pair := reflect.ArrayOf(2, t)
+	return func(i, j int) bool {
+		a, b := vals[i], vals[j]
+		if a.CanAddr() && b.CanAddr() {
+			return elemLess(unsafe.Pointer(a.UnsafeAddr()), unsafe.Pointer(b.UnsafeAddr()))
+		}
+		p := reflect.New(pair).Elem()
+		p.Index(0).Set(a)
+		p.Index(1).Set(b)
+		return elemLess(unsafe.Pointer(p.Index(0).UnsafeAddr()), unsafe.Pointer(p.Index(1).UnsafeAddr()))
+	}, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOfValues(t *testing.T) {
+	people := []TStringInt{{"c", 1}, {"a", 2}, {"b", 0}, {"a", 1}}
+	var vals []reflect.Value
+	for i, p := range people {
+		if i%2 == 0 {
+			vals = append(vals, reflect.ValueOf(&people[i]).Elem()) // addressable
+		} else {
+			vals = append(vals, reflect.ValueOf(p)) // a copy
+		}
+	}
+	less, err := OfValues(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(vals, less)
+	var got []string
+	for _, v := range vals {
+		got = append(got, fmt.Sprint(v.Interface()))
+	}
+	if got, want := strings.Join(got, " "), "{a 1} {a 2} {b 0} {c 1}"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	strs := []reflect.Value{reflect.ValueOf("B"), reflect.ValueOf("a")}
+	less, err = OfValues(strs, Fold())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !less(1, 0) || less(0, 1) {
+		t.Error("Fold not applied")
+	}
+
+	if less, err := OfValues(nil); less != nil || err != nil {
+		t.Errorf("OfValues(nil) = %v, %v", less != nil, err)
+	}
+	mixed := []reflect.Value{reflect.ValueOf(1), reflect.ValueOf("x")}
+	if _, err := OfValues(mixed); err == nil || err.Error() != "lesser: OfValues: value 1 is of type string, not int like value 0" {
+		t.Errorf("heterogeneous: err = %v", err)
+	}
+	if _, err := OfValues([]reflect.Value{reflect.ValueOf(1), {}}); err == nil {
+		t.Error("invalid value: no error")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import "reflect"
+
+// Argsort returns the permutation of indexes that sorts slice, using
+// the ordering of Of: slice[idx[0]], slice[idx[1]], ... are in order.
+// Equal elements keep their indexes in increasing order.
+//
+// The slice argument must be a slice. It is not modified.
+func Argsort(slice interface{}) []int {
+	return sortedIndices(reflect.ValueOf(slice).Len(), Of(slice))
+}
+
+// SortTracked sorts slice in place using the ordering of Of, like
+// SortSlice, and reports where each element came from: from[i] is
+// the index before sorting of the element now at index i. This is
+// what a UI needs to animate elements to their new positions. Equal
+// elements keep their relative order.
+//
+// The slice argument must be a slice.
+func SortTracked(slice interface{}) (from []int) {
+	v := NewView(slice)
+	from = append([]int(nil), v.perm...)
+	v.Apply()
+	return from
+}
+
+// A TiePolicy says how Ranks ranks a group of equal elements.
+type TiePolicy int
+
+const (
+	// TieAverage gives each element of the group the mean of the
+	// ranks they span, as is usual for rank statistics such as
+	// Spearman's correlation: two elements tied for ranks 2 and 3
+	// both get rank 2.5.
+	TieAverage TiePolicy = iota
+
+	// TieMin gives each element of the group the lowest of the
+	// ranks they span, as in "1224" ranking of competitions.
+	TieMin
+
+	// TieMax gives each element of the group the highest of the
+	// ranks they span.
+	TieMax
+)
+
+// Ranks returns the rank of each element of slice under the ordering
+// of Of: ranks[i] is the 1-based position of slice[i] in sorted
+// order. Elements that are equal, as described by FirstDuplicate, are
+// ranked according to ties.
+//
+// The slice argument must be a slice. It is not modified.
+func Ranks(slice interface{}, ties TiePolicy) []float64 {
+	less := Of(slice)
+	idx := sortedIndices(reflect.ValueOf(slice).Len(), less)
+	ranks := make([]float64, len(idx))
+	start := 0
+	for k := 1; k <= len(idx); k++ {
+		if k < len(idx) && !less(idx[k-1], idx[k]) {
+			continue
+		}
+		// idx[start:k] are equal, spanning ranks start+1 to k.
+		var r float64
+		switch ties {
+		case TieAverage:
+			r = float64(start+1+k) / 2
+		case TieMin:
+			r = float64(start + 1)
+		case TieMax:
+			r = float64(k)
+		default:
+			panic("lesser: invalid TiePolicy")
+		}
+		for _, i := range idx[start:k] {
+			ranks[i] = r
+		}
+		start = k
+	}
+	return ranks
+}
+
+// A View is a sorted view of a slice that doesn't move its elements.
+// Sorting a slice of large structs with sort.Slice copies elements
+// on every swap; a View sorts only a permutation of their indexes,
+// as with Argsort, and moves the elements when Apply is called, if
+// at all.
+type View struct {
+	rv   reflect.Value
+	perm []int
+}
+
+// NewView returns a View of slice in the order of Of.
+//
+// The slice argument must be a slice. It's not modified until Apply
+// is called, and must not be modified otherwise while the View is in
+// use.
+func NewView(slice interface{}) *View {
+	return &View{rv: reflect.ValueOf(slice), perm: Argsort(slice)}
+}
+
+// Len returns the number of elements in the view.
+func (v *View) Len() int { return len(v.perm) }
+
+// Index returns the index in the slice of the i'th element of the
+// view in sorted order.
+func (v *View) Index(i int) int { return v.perm[i] }
+
+// At returns the i'th element of the view in sorted order. It's
+// addressable, and shares memory with the slice.
+func (v *View) At(i int) reflect.Value { return v.rv.Index(v.perm[i]) }
+
+// Apply rearranges the elements of the slice into sorted order, in
+// place, moving each element at most once along its cycle of the
+// permutation. Afterwards the view is the identity on the sorted
+// slice.
+func (v *View) Apply() {
+	swap := reflect.Swapper(v.rv.Interface())
+	done := make([]bool, len(v.perm))
+	for start := range v.perm {
+		// Carry the element at start along its cycle, pulling
+		// each position's sorted element into place.
+		for cur := start; !done[cur]; {
+			done[cur] = true
+			next := v.perm[cur]
+			if next == start {
+				break
+			}
+			swap(cur, next)
+			cur = next
+		}
+	}
+	for i := range v.perm {
+		v.perm[i] = i
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 Brad Fitzpatrick. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lesser
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type wideRecord struct {
+	Key     string
+	Payload [64]int64
+}
+
+func TestArgsort(t *testing.T) {
+	s := []int{30, 10, 20, 10}
+	if got, want := Argsort(s), []int{1, 3, 2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Argsort = %v; want %v", got, want)
+	}
+	if s[0] != 30 {
+		t.Errorf("slice modified: %v", s)
+	}
+}
+
+func TestSortTracked(t *testing.T) {
+	s := []string{"d", "b", "a", "b", "c"}
+	orig := append([]string(nil), s...)
+	from := SortTracked(s)
+	if want := []string{"a", "b", "b", "c", "d"}; !reflect.DeepEqual(s, want) {
+		t.Errorf("sorted = %q; want %q", s, want)
+	}
+	if want := []int{2, 1, 3, 4, 0}; !reflect.DeepEqual(from, want) {
+		t.Errorf("from = %v; want %v", from, want)
+	}
+	for i, j := range from {
+		if s[i] != orig[j] {
+			t.Errorf("s[%d] = %q, but came from orig[%d] = %q", i, s[i], j, orig[j])
+		}
+	}
+}
+
+func TestRanks(t *testing.T) {
+	s := []int{30, 10, 20, 10, 30, 30}
+	tests := []struct {
+		ties TiePolicy
+		want []float64
+	}{
+		{TieAverage, []float64{5, 1.5, 3, 1.5, 5, 5}},
+		{TieMin, []float64{4, 1, 3, 1, 4, 4}},
+		{TieMax, []float64{6, 2, 3, 2, 6, 6}},
+	}
+	for _, tt := range tests {
+		if got := Ranks(s, tt.ties); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Ranks(%v, %d) = %v; want %v", s, tt.ties, got, tt.want)
+		}
+	}
+	if got := Ranks([]int{}, TieMin); len(got) != 0 {
+		t.Errorf("Ranks of empty slice = %v", got)
+	}
+}
+
+func TestView(t *testing.T) {
+	keys := []string{"e", "b", "a", "d", "c", "f", "a"}
+	s := make([]wideRecord, len(keys))
+	for i, k := range keys {
+		s[i].Key = k
+		s[i].Payload[0] = int64(i)
+	}
+	want := append([]wideRecord(nil), s...)
+	sort.SliceStable(want, Of(want))
+
+	v := NewView(s)
+	if v.Len() != len(s) {
+		t.Fatalf("Len = %d; want %d", v.Len(), len(s))
+	}
+	for i := 0; i < v.Len(); i++ {
+		got := v.At(i).Interface().(wideRecord)
+		if got != want[i] {
+			t.Errorf("At(%d) = %v, %v; want %v, %v", i, got.Key, got.Payload[0], want[i].Key, want[i].Payload[0])
+		}
+		if &s[v.Index(i)] != v.At(i).Addr().Interface().(*wideRecord) {
+			t.Errorf("At(%d) doesn't share memory with the slice", i)
+		}
+	}
+	if s[0].Key != "e" {
+		t.Fatal("NewView modified the slice")
+	}
+
+	v.Apply()
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("after Apply, slice not sorted")
+	}
+	for i := 0; i < v.Len(); i++ {
+		if v.Index(i) != i {
+			t.Errorf("after Apply, Index(%d) = %d", i, v.Index(i))
+		}
+	}
+}
+
+func BenchmarkWideRecords(b *testing.B) {
+	const n = 1000
+	src := make([]wideRecord, n)
+	for i := range src {
+		src[i].Key = string(rune('a' + (i*7919)%26))
+		src[i].Payload[0] = int64(i)
+	}
+	s := make([]wideRecord, n)
+	b.Run("sort.Slice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(s, src)
+			sort.Slice(s, Of(s))
+		}
+	})
+	b.Run("View.Apply", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(s, src)
+			NewView(s).Apply()
+		}
+	})
+}